@@ -0,0 +1,415 @@
+// Package accessreview generates effective-access reports for periodic access
+// reviews.
+//
+// A report is a matrix of every subject crossed with every target the subject
+// can access. For each pair, the report lists the verbs, target users and
+// Kubernetes groups the subject is granted, the policies granting them and
+// whether the access is only available just in time (JIT). Groups are expanded
+// into their member users so that access granted through IdP groups is
+// attributed to individual users.
+//
+// Use Collect to gather the input from the live API, Generate to compute the
+// report and Diff to compare two reports from different review cycles.
+package accessreview
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/subjecttype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// Subject is a BastionZero subject that may be granted access
+type Subject struct {
+	ID    string                  `json:"id"`
+	Email string                  `json:"email"`
+	Type  subjecttype.SubjectType `json:"type"`
+	// GroupIDs are the IDs of the IdP groups the subject is a member of
+	GroupIDs []string `json:"groupIds"`
+}
+
+// Target is a BastionZero target that access can be granted to
+type Target struct {
+	ID              string                `json:"id"`
+	Name            string                `json:"name"`
+	Type            targettype.TargetType `json:"type"`
+	EnvironmentID   string                `json:"environmentId"`
+	EnvironmentName string                `json:"environmentName"`
+}
+
+// Input is everything needed to compute an effective-access report
+type Input struct {
+	Subjects []Subject
+	// Groups maps IdP group IDs to group names
+	Groups                map[string]string
+	Targets               []Target
+	TargetConnectPolicies []policies.TargetConnectPolicy
+	KubernetesPolicies    []policies.KubernetesPolicy
+	ProxyPolicies         []policies.ProxyPolicy
+	JITPolicies           []policies.JITPolicy
+}
+
+// Grant describes access granted to a subject on a single target
+type Grant struct {
+	// Verbs are the target connect verbs granted. Empty for Cluster, Db and Web
+	// targets.
+	Verbs []string `json:"verbs"`
+	// TargetUsers are the Unix users (Bzero and DAC targets), database users
+	// (Db and Web targets) or Kubernetes users (Cluster targets) granted
+	TargetUsers []string `json:"targetUsers"`
+	// ClusterGroups are the Kubernetes groups granted. Empty for non-Cluster
+	// targets.
+	ClusterGroups []string `json:"clusterGroups"`
+	// Policies are the names of the policies that grant the access
+	Policies []string `json:"policies"`
+	// ViaGroups are the names of the IdP groups through which the subject
+	// receives the access. Empty if the access is granted to the subject
+	// directly.
+	ViaGroups []string `json:"viaGroups"`
+}
+
+// JITGrant describes access a subject may request just in time
+type JITGrant struct {
+	Grant
+
+	// AutomaticallyApproved is true if at least one of the JIT policies
+	// granting the access does not require approval
+	AutomaticallyApproved bool `json:"automaticallyApproved"`
+	// MaxDurationMinutes is the longest duration (in minutes) of the JIT
+	// policies granting the access
+	MaxDurationMinutes uint `json:"maxDurationMinutes"`
+	// JITPolicies are the names of the JIT policies that grant the access
+	JITPolicies []string `json:"jitPolicies"`
+}
+
+// Entry is a single cell of the effective-access matrix: the access one
+// subject has to one target
+type Entry struct {
+	SubjectID       string                  `json:"subjectId"`
+	SubjectEmail    string                  `json:"subjectEmail"`
+	SubjectType     subjecttype.SubjectType `json:"subjectType"`
+	TargetID        string                  `json:"targetId"`
+	TargetName      string                  `json:"targetName"`
+	TargetType      targettype.TargetType   `json:"targetType"`
+	EnvironmentID   string                  `json:"environmentId"`
+	EnvironmentName string                  `json:"environmentName"`
+
+	// Standing is the access that is always available to the subject. Nil if
+	// the subject only has JIT access.
+	Standing *Grant `json:"standing,omitempty"`
+	// JIT is the access the subject may request just in time. Nil if no JIT
+	// policy applies.
+	JIT *JITGrant `json:"jit,omitempty"`
+	// RequiresJIT is true if the subject has no standing access to the target
+	// and must request it just in time
+	RequiresJIT bool `json:"requiresJit"`
+}
+
+// Report is an effective-access matrix
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Entries     []Entry   `json:"entries"`
+}
+
+// key uniquely identifies an entry within a report
+func (e *Entry) key() string {
+	return e.SubjectID + "/" + e.TargetID
+}
+
+// policyAccess is the normalized access granted by a single target connect,
+// Kubernetes or proxy policy
+type policyAccess struct {
+	id            string
+	name          string
+	subjects      map[string]struct{}
+	groups        map[string]struct{}
+	targetKinds   []targettype.TargetType
+	targets       map[string]struct{}
+	environments  map[string]struct{}
+	verbs         []string
+	targetUsers   []string
+	clusterGroups []string
+}
+
+func newPolicyAccess(p policies.PolicyInterface, kinds []targettype.TargetType, targets []policies.Target, clusters []policies.Cluster, envs []policies.Environment) *policyAccess {
+	pa := &policyAccess{
+		id:           p.GetID(),
+		name:         p.GetName(),
+		subjects:     make(map[string]struct{}),
+		groups:       make(map[string]struct{}),
+		targetKinds:  kinds,
+		targets:      make(map[string]struct{}),
+		environments: make(map[string]struct{}),
+	}
+	for _, s := range p.GetSubjects() {
+		pa.subjects[s.ID] = struct{}{}
+	}
+	for _, g := range p.GetGroups() {
+		pa.groups[g.ID] = struct{}{}
+	}
+	for _, t := range targets {
+		pa.targets[t.ID] = struct{}{}
+	}
+	for _, c := range clusters {
+		pa.targets[c.ID] = struct{}{}
+	}
+	for _, e := range envs {
+		pa.environments[e.ID] = struct{}{}
+	}
+	return pa
+}
+
+func (pa *policyAccess) appliesToTarget(t *Target) bool {
+	kindMatches := false
+	for _, k := range pa.targetKinds {
+		if k == t.Type {
+			kindMatches = true
+			break
+		}
+	}
+	if !kindMatches {
+		return false
+	}
+	if _, ok := pa.targets[t.ID]; ok {
+		return true
+	}
+	_, ok := pa.environments[t.EnvironmentID]
+	return ok
+}
+
+// appliesToSubject returns whether the policy applies to the subject and, if
+// it only applies through group membership, the IDs of the matching groups
+func (pa *policyAccess) appliesToSubject(s *Subject) (bool, []string) {
+	if _, ok := pa.subjects[s.ID]; ok {
+		return true, nil
+	}
+	var via []string
+	for _, g := range s.GroupIDs {
+		if _, ok := pa.groups[g]; ok {
+			via = append(via, g)
+		}
+	}
+	return len(via) > 0, via
+}
+
+func normalizePolicies(in *Input) map[string]*policyAccess {
+	result := make(map[string]*policyAccess)
+
+	for i := range in.TargetConnectPolicies {
+		p := &in.TargetConnectPolicies[i]
+		pa := newPolicyAccess(p, []targettype.TargetType{targettype.Bzero, targettype.DynamicAccessConfig}, p.GetTargets(), nil, p.GetEnvironments())
+		pa.verbs = p.GetVerbsAsStringList()
+		pa.targetUsers = p.GetTargetUsersAsStringList()
+		result[pa.id] = pa
+	}
+	for i := range in.KubernetesPolicies {
+		p := &in.KubernetesPolicies[i]
+		pa := newPolicyAccess(p, []targettype.TargetType{targettype.Cluster}, nil, p.GetClusters(), p.GetEnvironments())
+		for _, u := range p.GetClusterUsers() {
+			pa.targetUsers = append(pa.targetUsers, u.Name)
+		}
+		for _, g := range p.GetClusterGroups() {
+			pa.clusterGroups = append(pa.clusterGroups, g.Name)
+		}
+		result[pa.id] = pa
+	}
+	for i := range in.ProxyPolicies {
+		p := &in.ProxyPolicies[i]
+		pa := newPolicyAccess(p, []targettype.TargetType{targettype.Db, targettype.Web}, p.GetTargets(), nil, p.GetEnvironments())
+		pa.targetUsers = p.GetTargetUsersAsStringList()
+		result[pa.id] = pa
+	}
+
+	return result
+}
+
+// grantBuilder accumulates the grants of several policies into sets
+type grantBuilder struct {
+	verbs, targetUsers, clusterGroups, policies, viaGroups map[string]struct{}
+}
+
+func newGrantBuilder() *grantBuilder {
+	return &grantBuilder{
+		verbs:         make(map[string]struct{}),
+		targetUsers:   make(map[string]struct{}),
+		clusterGroups: make(map[string]struct{}),
+		policies:      make(map[string]struct{}),
+		viaGroups:     make(map[string]struct{}),
+	}
+}
+
+func (b *grantBuilder) add(pa *policyAccess, viaGroups []string, groupNames map[string]string) {
+	addAll(b.verbs, pa.verbs)
+	addAll(b.targetUsers, pa.targetUsers)
+	addAll(b.clusterGroups, pa.clusterGroups)
+	b.policies[pa.name] = struct{}{}
+	for _, g := range viaGroups {
+		name := groupNames[g]
+		if name == "" {
+			name = g
+		}
+		b.viaGroups[name] = struct{}{}
+	}
+}
+
+func (b *grantBuilder) build() Grant {
+	return Grant{
+		Verbs:         sortedKeys(b.verbs),
+		TargetUsers:   sortedKeys(b.targetUsers),
+		ClusterGroups: sortedKeys(b.clusterGroups),
+		Policies:      sortedKeys(b.policies),
+		ViaGroups:     sortedKeys(b.viaGroups),
+	}
+}
+
+// Generate computes the effective-access matrix for in. generatedAt is
+// recorded in the report; pass time.Now() for a live report.
+func Generate(in *Input, generatedAt time.Time) *Report {
+	access := normalizePolicies(in)
+
+	report := &Report{GeneratedAt: generatedAt, Entries: []Entry{}}
+	for si := range in.Subjects {
+		subject := &in.Subjects[si]
+
+		// Collect the policies that apply to the subject directly or through
+		// a group, and the JIT policies that make other policies requestable
+		type applicable struct {
+			pa  *policyAccess
+			via []string
+		}
+		var standing []applicable
+		for _, pa := range access {
+			if ok, via := pa.appliesToSubject(subject); ok {
+				standing = append(standing, applicable{pa, via})
+			}
+		}
+
+		type requestable struct {
+			applicable
+			jit *policies.JITPolicy
+		}
+		var jit []requestable
+		for ji := range in.JITPolicies {
+			jp := &in.JITPolicies[ji]
+			if ok, via := jitAppliesToSubject(jp, subject); ok {
+				for _, child := range jp.ChildPolicies {
+					if pa, ok := access[child.ID]; ok {
+						jit = append(jit, requestable{applicable{pa, via}, jp})
+					}
+				}
+			}
+		}
+
+		for ti := range in.Targets {
+			target := &in.Targets[ti]
+
+			standingGrant := newGrantBuilder()
+			hasStanding := false
+			for _, a := range standing {
+				if a.pa.appliesToTarget(target) {
+					standingGrant.add(a.pa, a.via, in.Groups)
+					hasStanding = true
+				}
+			}
+
+			jitGrant := newGrantBuilder()
+			hasJIT := false
+			autoApproved := false
+			var maxDuration uint
+			jitPolicies := make(map[string]struct{})
+			for _, r := range jit {
+				if r.pa.appliesToTarget(target) {
+					jitGrant.add(r.pa, r.via, in.Groups)
+					hasJIT = true
+					autoApproved = autoApproved || r.jit.AutomaticallyApproved
+					if r.jit.Duration > maxDuration {
+						maxDuration = r.jit.Duration
+					}
+					jitPolicies[r.jit.Name] = struct{}{}
+				}
+			}
+
+			if !hasStanding && !hasJIT {
+				continue
+			}
+
+			entry := Entry{
+				SubjectID:       subject.ID,
+				SubjectEmail:    subject.Email,
+				SubjectType:     subject.Type,
+				TargetID:        target.ID,
+				TargetName:      target.Name,
+				TargetType:      target.Type,
+				EnvironmentID:   target.EnvironmentID,
+				EnvironmentName: target.EnvironmentName,
+				RequiresJIT:     !hasStanding,
+			}
+			if hasStanding {
+				g := standingGrant.build()
+				entry.Standing = &g
+			}
+			if hasJIT {
+				entry.JIT = &JITGrant{
+					Grant:                 jitGrant.build(),
+					AutomaticallyApproved: autoApproved,
+					MaxDurationMinutes:    maxDuration,
+					JITPolicies:           sortedKeys(jitPolicies),
+				}
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+
+	report.sort()
+	return report
+}
+
+func jitAppliesToSubject(p *policies.JITPolicy, s *Subject) (bool, []string) {
+	for _, ps := range p.Subjects {
+		if ps.ID == s.ID {
+			return true, nil
+		}
+	}
+	var via []string
+	for _, pg := range p.Groups {
+		for _, g := range s.GroupIDs {
+			if pg.ID == g {
+				via = append(via, g)
+			}
+		}
+	}
+	return len(via) > 0, via
+}
+
+func (r *Report) sort() {
+	sort.SliceStable(r.Entries, func(i, j int) bool {
+		a, b := r.Entries[i], r.Entries[j]
+		if a.SubjectEmail != b.SubjectEmail {
+			return a.SubjectEmail < b.SubjectEmail
+		}
+		if a.EnvironmentName != b.EnvironmentName {
+			return a.EnvironmentName < b.EnvironmentName
+		}
+		if a.TargetName != b.TargetName {
+			return a.TargetName < b.TargetName
+		}
+		return a.key() < b.key()
+	})
+}
+
+func addAll(s map[string]struct{}, elems []string) {
+	for _, e := range elems {
+		s[e] = struct{}{}
+	}
+}
+
+func sortedKeys(s map[string]struct{}) []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
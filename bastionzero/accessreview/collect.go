@@ -0,0 +1,178 @@
+package accessreview
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/subjecttype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// Collect gathers the input for an effective-access report from the live API.
+// The client must be authenticated as an admin.
+//
+// Group membership is fetched from the identity provider for every user, so
+// Collect issues one request per user in the organization.
+func Collect(ctx context.Context, client *bastionzero.Client) (*Input, error) {
+	in := &Input{Groups: make(map[string]string)}
+
+	groups, _, err := client.Organization.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, g := range groups {
+		in.Groups[g.ID] = g.Name
+	}
+
+	subjects, _, err := client.Subjects.ListSubjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	for _, s := range subjects {
+		subject := Subject{ID: s.ID, Email: s.Email, Type: s.Type, GroupIDs: []string{}}
+		// Only users are members of IdP groups
+		if s.Type == subjecttype.User {
+			userGroups, _, err := client.Organization.FetchUserGroups(ctx, s.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch groups of user %s: %w", s.Email, err)
+			}
+			for _, g := range userGroups {
+				subject.GroupIDs = append(subject.GroupIDs, g.ID)
+				if _, ok := in.Groups[g.ID]; !ok {
+					in.Groups[g.ID] = g.Name
+				}
+			}
+		}
+		in.Subjects = append(in.Subjects, subject)
+	}
+
+	if in.Targets, err = collectTargets(ctx, client); err != nil {
+		return nil, err
+	}
+
+	if in.TargetConnectPolicies, _, err = client.Policies.ListTargetConnectPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list target connect policies: %w", err)
+	}
+	if in.KubernetesPolicies, _, err = client.Policies.ListKubernetesPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list Kubernetes policies: %w", err)
+	}
+	if in.ProxyPolicies, _, err = client.Policies.ListProxyPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list proxy policies: %w", err)
+	}
+	if in.JITPolicies, _, err = client.Policies.ListJITPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list JIT policies: %w", err)
+	}
+
+	return in, nil
+}
+
+// collectTargets lists every target in the organization. The TargetsService
+// listings are the source of truth for which targets exist and their type;
+// ListAllTargets is used to resolve environment names.
+func collectTargets(ctx context.Context, client *bastionzero.Client) ([]Target, error) {
+	var result []Target
+
+	bzeroTargets, _, err := client.Targets.ListBzeroTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Bzero targets: %w", err)
+	}
+	for _, t := range bzeroTargets {
+		result = append(result, Target{ID: t.ID, Name: t.Name, Type: targettype.Bzero, EnvironmentID: t.EnvironmentID})
+	}
+
+	clusterTargets, _, err := client.Targets.ListClusterTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+	}
+	for _, t := range clusterTargets {
+		result = append(result, Target{ID: t.ID, Name: t.Name, Type: targettype.Cluster, EnvironmentID: t.EnvironmentID})
+	}
+
+	dbTargets, _, err := client.Targets.ListDatabaseTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Database targets: %w", err)
+	}
+	for _, t := range dbTargets {
+		result = append(result, Target{ID: t.ID, Name: t.Name, Type: targettype.Db, EnvironmentID: t.EnvironmentID})
+	}
+
+	webTargets, _, err := client.Targets.ListWebTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Web targets: %w", err)
+	}
+	for _, t := range webTargets {
+		result = append(result, Target{ID: t.ID, Name: t.Name, Type: targettype.Web, EnvironmentID: t.EnvironmentID})
+	}
+
+	dacs, _, err := client.Targets.ListDynamicAccessConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dynamic access configurations: %w", err)
+	}
+	for _, d := range dacs {
+		result = append(result, Target{ID: d.ID, Name: d.Name, Type: targettype.DynamicAccessConfig, EnvironmentID: d.EnvironmentId})
+	}
+
+	envNames := make(map[string]string)
+	allTargets, _, err := client.AllTargets.ListAllTargets(ctx, &targets_disambiguated.ListAllTargetsOptions{AllTargetsInOrg: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list all targets: %w", err)
+	}
+	addEnv := func(t targets_disambiguated.Target) {
+		if t.EnvironmentName != "" {
+			envNames[t.EnvironmentID] = t.EnvironmentName
+		}
+	}
+	for _, t := range allTargets.Db {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.Kubernetes {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.FileTransfer {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.Rdp {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.Shell {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.Ssh {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.SqlServer {
+		addEnv(t.Target)
+	}
+	for _, t := range allTargets.Web {
+		addEnv(t.Target)
+	}
+
+	// Fall back to the environments list for environments whose targets are
+	// not part of the disambiguated listing
+	if hasUnnamedEnvironment(result, envNames) {
+		envs, _, err := client.Environments.ListEnvironments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list environments: %w", err)
+		}
+		for _, e := range envs {
+			envNames[e.ID] = e.Name
+		}
+	}
+
+	for i := range result {
+		result[i].EnvironmentName = envNames[result[i].EnvironmentID]
+	}
+
+	return result, nil
+}
+
+func hasUnnamedEnvironment(targets []Target, envNames map[string]string) bool {
+	for _, t := range targets {
+		if _, ok := envNames[t.EnvironmentID]; !ok {
+			return true
+		}
+	}
+	return false
+}
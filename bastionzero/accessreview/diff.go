@@ -0,0 +1,219 @@
+package accessreview
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ChangeKind describes how a subject's access to a target changed between two
+// reports
+type ChangeKind string
+
+const (
+	// Granted means the subject had no access to the target in the old report
+	Granted ChangeKind = "Granted"
+	// Revoked means the subject has no access to the target in the new report
+	Revoked ChangeKind = "Revoked"
+	// Modified means the subject's access to the target changed
+	Modified ChangeKind = "Modified"
+)
+
+// Change describes how one subject's access to one target changed
+type Change struct {
+	Kind            ChangeKind `json:"kind"`
+	SubjectID       string     `json:"subjectId"`
+	SubjectEmail    string     `json:"subjectEmail"`
+	TargetID        string     `json:"targetId"`
+	TargetName      string     `json:"targetName"`
+	EnvironmentName string     `json:"environmentName"`
+	// GrantedAccess lists the access items present only in the new report
+	// (e.g. "verb:Shell", "targetUser:root", "jit:verb:Shell")
+	GrantedAccess []string `json:"grantedAccess"`
+	// RevokedAccess lists the access items present only in the old report
+	RevokedAccess []string `json:"revokedAccess"`
+}
+
+// DiffReport is the difference between two effective-access reports
+type DiffReport struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Changes []Change  `json:"changes"`
+}
+
+// accessItems flattens the access described by an entry into a set of
+// comparable strings. Policy names are excluded so that renaming or
+// restructuring policies without changing the effective access is not
+// reported as a change.
+func (e *Entry) accessItems() map[string]struct{} {
+	items := make(map[string]struct{})
+	add := func(prefix string, g *Grant) {
+		for _, v := range g.Verbs {
+			items[prefix+"verb:"+v] = struct{}{}
+		}
+		for _, u := range g.TargetUsers {
+			items[prefix+"targetUser:"+u] = struct{}{}
+		}
+		for _, cg := range g.ClusterGroups {
+			items[prefix+"clusterGroup:"+cg] = struct{}{}
+		}
+	}
+	if e.Standing != nil {
+		add("", e.Standing)
+	}
+	if e.JIT != nil {
+		add("jit:", &e.JIT.Grant)
+		if e.JIT.AutomaticallyApproved {
+			items["jit:automaticallyApproved"] = struct{}{}
+		}
+		items["jit:maxDurationMinutes:"+strconv.FormatUint(uint64(e.JIT.MaxDurationMinutes), 10)] = struct{}{}
+	}
+	if e.RequiresJIT {
+		items["requiresJit"] = struct{}{}
+	}
+	return items
+}
+
+// Diff compares two reports and returns the access that was granted and
+// revoked between them
+func Diff(old, new *Report) *DiffReport {
+	oldEntries := make(map[string]*Entry, len(old.Entries))
+	for i := range old.Entries {
+		oldEntries[old.Entries[i].key()] = &old.Entries[i]
+	}
+	newEntries := make(map[string]*Entry, len(new.Entries))
+	for i := range new.Entries {
+		newEntries[new.Entries[i].key()] = &new.Entries[i]
+	}
+
+	diff := &DiffReport{From: old.GeneratedAt, To: new.GeneratedAt, Changes: []Change{}}
+	newChange := func(kind ChangeKind, e *Entry) Change {
+		return Change{
+			Kind:            kind,
+			SubjectID:       e.SubjectID,
+			SubjectEmail:    e.SubjectEmail,
+			TargetID:        e.TargetID,
+			TargetName:      e.TargetName,
+			EnvironmentName: e.EnvironmentName,
+			GrantedAccess:   []string{},
+			RevokedAccess:   []string{},
+		}
+	}
+
+	for k, ne := range newEntries {
+		oe, ok := oldEntries[k]
+		if !ok {
+			c := newChange(Granted, ne)
+			c.GrantedAccess = sortedKeys(ne.accessItems())
+			diff.Changes = append(diff.Changes, c)
+			continue
+		}
+
+		oldItems, newItems := oe.accessItems(), ne.accessItems()
+		c := newChange(Modified, ne)
+		for item := range newItems {
+			if _, ok := oldItems[item]; !ok {
+				c.GrantedAccess = append(c.GrantedAccess, item)
+			}
+		}
+		for item := range oldItems {
+			if _, ok := newItems[item]; !ok {
+				c.RevokedAccess = append(c.RevokedAccess, item)
+			}
+		}
+		if len(c.GrantedAccess) > 0 || len(c.RevokedAccess) > 0 {
+			sort.Strings(c.GrantedAccess)
+			sort.Strings(c.RevokedAccess)
+			diff.Changes = append(diff.Changes, c)
+		}
+	}
+
+	for k, oe := range oldEntries {
+		if _, ok := newEntries[k]; !ok {
+			c := newChange(Revoked, oe)
+			c.RevokedAccess = sortedKeys(oe.accessItems())
+			diff.Changes = append(diff.Changes, c)
+		}
+	}
+
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		a, b := diff.Changes[i], diff.Changes[j]
+		if a.SubjectEmail != b.SubjectEmail {
+			return a.SubjectEmail < b.SubjectEmail
+		}
+		if a.EnvironmentName != b.EnvironmentName {
+			return a.EnvironmentName < b.EnvironmentName
+		}
+		if a.TargetName != b.TargetName {
+			return a.TargetName < b.TargetName
+		}
+		return a.TargetID < b.TargetID
+	})
+
+	return diff
+}
+
+// WriteJSON writes the diff to w as indented JSON
+func (d *DiffReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteCSV writes the diff to w as CSV with one row per change. Lists are
+// joined with semicolons.
+func (d *DiffReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"kind", "subject_id", "subject_email", "target_id", "target_name", "environment_name", "granted_access", "revoked_access"}); err != nil {
+		return err
+	}
+	for _, c := range d.Changes {
+		row := []string{string(c.Kind), c.SubjectID, c.SubjectEmail, c.TargetID, c.TargetName, c.EnvironmentName, join(c.GrantedAccess), join(c.RevokedAccess)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var diffTemplate = template.Must(template.New("diff").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>BastionZero effective access changes</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<h1>BastionZero effective access changes</h1>
+<p>From {{ time .From }} to {{ time .To }} &middot; {{ len .Changes }} changes</p>
+<table>
+<thead>
+<tr><th>Change</th><th>Subject</th><th>Environment</th><th>Target</th><th>Granted</th><th>Revoked</th></tr>
+</thead>
+<tbody>
+{{- range .Changes }}
+<tr class="{{ if eq .Kind "Granted" }}granted{{ else if eq .Kind "Revoked" }}revoked{{ else }}modified{{ end }}">
+<td>{{ .Kind }}</td>
+<td>{{ .SubjectEmail }}</td>
+<td>{{ .EnvironmentName }}</td>
+<td>{{ .TargetName }}</td>
+<td>{{ join .GrantedAccess }}</td>
+<td>{{ join .RevokedAccess }}</td>
+</tr>
+{{- end }}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the diff to w as a self-contained HTML document with
+// granted and revoked access highlighted
+func (d *DiffReport) WriteHTML(w io.Writer) error {
+	return diffTemplate.Execute(w, d)
+}
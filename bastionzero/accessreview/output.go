@@ -0,0 +1,150 @@
+package accessreview
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listSeparator separates the elements of a list within a single CSV cell
+const listSeparator = ";"
+
+// WriteJSON writes the report to w as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a report previously written with WriteJSON
+func ReadJSON(rd io.Reader) (*Report, error) {
+	r := new(Report)
+	if err := json.NewDecoder(rd).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var csvHeader = []string{
+	"subject_id", "subject_email", "subject_type",
+	"target_id", "target_name", "target_type", "environment_id", "environment_name",
+	"requires_jit",
+	"verbs", "target_users", "cluster_groups", "policies", "via_groups",
+	"jit_verbs", "jit_target_users", "jit_cluster_groups", "jit_policies", "jit_via_groups",
+	"jit_automatically_approved", "jit_max_duration_minutes",
+}
+
+// WriteCSV writes the report to w as CSV with one row per entry. Lists are
+// joined with semicolons.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, e := range r.Entries {
+		standing := e.Standing
+		if standing == nil {
+			standing = &Grant{}
+		}
+		jit := e.JIT
+		if jit == nil {
+			jit = &JITGrant{}
+		}
+
+		var autoApproved, maxDuration string
+		if e.JIT != nil {
+			autoApproved = strconv.FormatBool(jit.AutomaticallyApproved)
+			maxDuration = strconv.FormatUint(uint64(jit.MaxDurationMinutes), 10)
+		}
+
+		row := []string{
+			e.SubjectID, e.SubjectEmail, string(e.SubjectType),
+			e.TargetID, e.TargetName, string(e.TargetType), e.EnvironmentID, e.EnvironmentName,
+			strconv.FormatBool(e.RequiresJIT),
+			join(standing.Verbs), join(standing.TargetUsers), join(standing.ClusterGroups), join(standing.Policies), join(standing.ViaGroups),
+			join(jit.Verbs), join(jit.TargetUsers), join(jit.ClusterGroups), join(jit.JITPolicies), join(jit.ViaGroups),
+			autoApproved, maxDuration,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func join(elems []string) string {
+	return strings.Join(elems, listSeparator)
+}
+
+var templateFuncs = template.FuncMap{
+	"join": func(elems []string) string { return strings.Join(elems, ", ") },
+	"time": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+// htmlStyle is shared by the report and diff HTML outputs so that both are
+// self-contained documents
+const htmlStyle = `
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.5em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; position: sticky; top: 0; }
+tr:nth-child(even) td { background: #fbfcfd; }
+.jit { color: #9a6700; font-weight: bold; }
+.granted td { background: #dafbe1 !important; }
+.revoked td { background: #ffebe9 !important; }
+.modified td { background: #fff8c5 !important; }
+`
+
+var reportTemplate = template.Must(template.New("report").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>BastionZero effective access report</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<h1>BastionZero effective access report</h1>
+<p>Generated at {{ time .GeneratedAt }} &middot; {{ len .Entries }} entries</p>
+<table>
+<thead>
+<tr>
+<th>Subject</th><th>Type</th><th>Environment</th><th>Target</th><th>Target type</th>
+<th>Verbs</th><th>Target users</th><th>Cluster groups</th><th>Policies</th><th>Via groups</th>
+<th>JIT</th>
+</tr>
+</thead>
+<tbody>
+{{- range .Entries }}
+{{- $entry := . }}
+<tr>
+<td>{{ .SubjectEmail }}</td>
+<td>{{ .SubjectType }}</td>
+<td>{{ .EnvironmentName }}</td>
+<td>{{ .TargetName }}</td>
+<td>{{ .TargetType }}</td>
+{{- with .Standing }}
+<td>{{ join .Verbs }}</td><td>{{ join .TargetUsers }}</td><td>{{ join .ClusterGroups }}</td><td>{{ join .Policies }}</td><td>{{ join .ViaGroups }}</td>
+{{- else }}
+<td></td><td></td><td></td><td></td><td></td>
+{{- end }}
+<td>{{ with .JIT }}<span{{ if $entry.RequiresJIT }} class="jit"{{ end }}>{{ join .JITPolicies }}</span>{{ if .AutomaticallyApproved }} (auto-approved){{ end }}, max {{ .MaxDurationMinutes }}m: {{ join .Verbs }} {{ join .TargetUsers }} {{ join .ClusterGroups }}{{ end }}</td>
+</tr>
+{{- end }}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report to w as a self-contained HTML document
+func (r *Report) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
@@ -3,6 +3,7 @@ package apierror
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 )
@@ -57,3 +58,16 @@ func IsAPIErrorStatusCode(err error, code int) bool {
 		return false
 	}
 }
+
+// IsTransient returns true when the error is likely to go away if the request
+// is retried: a network error, or an *apierror.ErrorResponse with status code
+// 429 or 5xx.
+func IsTransient(err error) bool {
+	bzeroError := &ErrorResponse{}
+	if errors.As(err, &bzeroError) {
+		code := bzeroError.Response.StatusCode
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,74 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError reports the fields of a request that failed client-side
+// validation. Errors has the same shape as ErrorResponse.ValidationErrors: it
+// maps each invalid field (by JSON name) to a list of messages.
+type ValidationError struct {
+	Errors map[string][]string
+}
+
+// Add records an error message for field
+func (e *ValidationError) Add(field string, format string, args ...interface{}) {
+	if e.Errors == nil {
+		e.Errors = make(map[string][]string)
+	}
+	e.Errors[field] = append(e.Errors[field], fmt.Sprintf(format, args...))
+}
+
+// Merge records the errors of err under prefix (e.g. "databaseAuthenticationConfig")
+// if err is a *ValidationError. Any other non-nil error is recorded as a
+// message for prefix.
+func (e *ValidationError) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	other := &ValidationError{}
+	if !errors.As(err, &other) {
+		e.Add(prefix, "%s", err.Error())
+		return
+	}
+	for field, messages := range other.Errors {
+		if prefix != "" {
+			field = prefix + "." + field
+		}
+		for _, m := range messages {
+			e.Add(field, "%s", m)
+		}
+	}
+}
+
+// Err returns e if any errors were recorded, and nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var prettyMsg string = "Invalid request:"
+	for _, field := range fields {
+		prettyMsg += fmt.Sprintf(" %v: %v", field, strings.Join(e.Errors[field], ", "))
+	}
+	return prettyMsg
+}
+
+// IsValidationError returns true when the error is of *apierror.ValidationError
+// type
+func IsValidationError(err error) bool {
+	validationError := &ValidationError{}
+	return errors.As(err, &validationError)
+}
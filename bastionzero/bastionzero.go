@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/agents"
@@ -19,6 +20,7 @@ import (
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/environments"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/events"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/githubactions"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/jitrequests"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/mfa"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/oktapublickeys"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/organization"
@@ -27,6 +29,7 @@ import (
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/sessionrecordings"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/subjects"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dbauthconfig"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/users"
 	"github.com/bastionzero/bastionzero-sdk-go/internal"
@@ -56,6 +59,19 @@ type Client struct {
 	// Optional extra HTTP headers to set on every request to the API
 	headers map[string]string
 
+	// If true, request bodies are validated before they are sent. See
+	// WithRequestValidation
+	validateRequests bool
+
+	// Supported database authentication configurations. Fetched once when
+	// validating requests that set a DatabaseAuthenticationConfig
+	dbAuthConfigsMu sync.Mutex
+	dbAuthConfigs   []dbauthconfig.DatabaseAuthenticationConfig
+
+	// If set, every decoded response is inspected for unknown enum values and
+	// fields. See WithStrictDecoding
+	decodeHook DecodeHook
+
 	common client.Service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// Services used for talking to different parts of the BastionZero API.
@@ -67,6 +83,7 @@ type Client struct {
 	Environments         *environments.EnvironmentsService
 	Events               *events.EventsService
 	GitHubActions        *githubactions.GitHubActionsService
+	JITRequests          *jitrequests.JITRequestsService
 	MFA                  *mfa.MFAService
 	OktaPublicKeys       *oktapublickeys.OktaPublicKeysService
 	Organization         *organization.OrganizationService
@@ -103,6 +120,7 @@ func NewClient(httpClient *http.Client) *Client {
 	c.Environments = (*environments.EnvironmentsService)(&c.common)
 	c.Events = (*events.EventsService)(&c.common)
 	c.GitHubActions = (*githubactions.GitHubActionsService)(&c.common)
+	c.JITRequests = (*jitrequests.JITRequestsService)(&c.common)
 	c.MFA = (*mfa.MFAService)(&c.common)
 	c.OktaPublicKeys = (*oktapublickeys.OktaPublicKeysService)(&c.common)
 	c.Organization = (*organization.OrganizationService)(&c.common)
@@ -141,6 +159,11 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 	case io.Writer:
 		_, err = io.Copy(v, resp.Body)
 	default:
+		if c.decodeHook != nil {
+			err = c.decodeStrict(req, resp, v)
+			break
+		}
+
 		decErr := json.NewDecoder(resp.Body).Decode(v)
 		if decErr == io.EOF {
 			// ignore EOF errors caused by empty response body
@@ -173,6 +196,12 @@ func (c *Client) NewRequest(ctx context.Context, method, urlStr string, body int
 		}
 
 	default:
+		if c.validateRequests && body != nil {
+			if err := c.validateRequest(ctx, body); err != nil {
+				return nil, err
+			}
+		}
+
 		buf := new(bytes.Buffer)
 		if body != nil {
 			err = json.NewEncoder(buf).Encode(body)
@@ -298,6 +327,30 @@ func WithRequestHeaders(headers map[string]string) ClientOpt {
 	}
 }
 
+// WithRequestValidation is a client option that validates request bodies that
+// implement Validator before they are sent. Invalid requests fail with an
+// *apierror.ValidationError without making an API call. Requests that set a
+// DatabaseAuthenticationConfig are also checked against the configurations
+// returned by ListDatabaseAuthenticationConfigs, which are fetched once.
+func WithRequestValidation() ClientOpt {
+	return func(c *Client) error {
+		c.validateRequests = true
+		return nil
+	}
+}
+
+// WithStrictDecoding is a client option that inspects every decoded response
+// for enum values and JSON fields that are unknown to this SDK. hook is called
+// with the request and a report whenever something unknown is found. Unknown
+// values never cause a request to fail. Use (*decoding.Collector).Record as
+// the hook to aggregate reports across calls.
+func WithStrictDecoding(hook DecodeHook) ClientOpt {
+	return func(c *Client) error {
+		c.decodeHook = hook
+		return nil
+	}
+}
+
 // PtrTo returns a pointer to the provided input.
 func PtrTo[T any](v T) *T {
 	return &v
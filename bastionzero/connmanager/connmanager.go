@@ -0,0 +1,515 @@
+// Package connmanager manages the lifecycle of the connections a process
+// creates.
+//
+// A Manager creates connections on behalf of the caller and tracks them until
+// they are closed. It can wait for a connection to reach a state, closes
+// connections that outlive the maximum lifetime configured for their type,
+// and closes every tracked connection when it is shut down. To close
+// connections when the process is interrupted, pass a context from
+// signal.NotifyContext to Run and wait for Run to return before exiting:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//	defer stop()
+//	m := connmanager.NewManager(client, nil)
+//	go m.Run(ctx)
+//
+// TerminateConnections closes every open connection, created by any process,
+// that matches a Filter by target, environment, subject, connection type or
+// age. It supports dry runs for incident runbooks and reports the outcome of
+// each connection.
+package connmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections/connectionstate"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections/connectiontype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/verbtype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+const (
+	defaultCheckInterval  = 10 * time.Second
+	defaultPollInterval   = 2 * time.Second
+	defaultCloseTimeout   = 30 * time.Second
+	defaultMaxConcurrency = 10
+)
+
+// ErrShutdown is returned when creating or tracking a connection after the
+// Manager was shut down
+var ErrShutdown = errors.New("connection manager is shut down")
+
+// CloseReason describes why a Manager closed a connection
+type CloseReason string
+
+const (
+	// Requested means the connection was closed by Close
+	Requested CloseReason = "Requested"
+	// LifetimeExceeded means the connection outlived its maximum lifetime
+	LifetimeExceeded CloseReason = "LifetimeExceeded"
+	// Shutdown means the connection was closed by Shutdown or because the
+	// context passed to Run was cancelled
+	Shutdown CloseReason = "Shutdown"
+	// ClosedElsewhere means the connection was found closed while waiting for
+	// its state
+	ClosedElsewhere CloseReason = "ClosedElsewhere"
+)
+
+// Connection is a connection tracked by a Manager
+type Connection struct {
+	ID       string
+	Type     connectiontype.ConnectionType
+	TargetID string
+	// TrackedAt is the time the Manager started tracking the connection
+	TrackedAt time.Time
+	// Deadline is the time the connection is closed for exceeding its
+	// maximum lifetime. It is zero if the connection's type has none.
+	Deadline time.Time
+}
+
+// Options specifies the optional parameters to NewManager
+type Options struct {
+	// MaxLifetime is the maximum lifetime of connections by type
+	MaxLifetime map[connectiontype.ConnectionType]time.Duration
+	// DefaultMaxLifetime is the maximum lifetime of connections whose type is
+	// missing from MaxLifetime. If zero, they are kept open until closed.
+	DefaultMaxLifetime time.Duration
+	// CheckInterval is the time between checks for connections that exceeded
+	// their maximum lifetime. Defaults to 10 seconds.
+	CheckInterval time.Duration
+	// PollInterval is the time between checks of WaitForState. Defaults to 2
+	// seconds.
+	PollInterval time.Duration
+	// CloseTimeout bounds the time Run spends closing connections after its
+	// context is cancelled. Defaults to 30 seconds.
+	CloseTimeout time.Duration
+	// OnClose is called after a tracked connection is closed
+	OnClose func(c Connection, reason CloseReason)
+	// OnError is called with errors from closing connections in Run. Errors
+	// are dropped if nil.
+	OnError func(error)
+}
+
+// Manager creates connections and closes them when they are no longer
+// needed. It is safe for concurrent use.
+type Manager struct {
+	// Client is used to create and close connections
+	Client *bastionzero.Client
+
+	opts Options
+
+	mu       sync.Mutex
+	conns    map[string]*entry
+	shutdown bool
+}
+
+type entry struct {
+	Connection
+	// closing is true while a close request is in flight
+	closing bool
+}
+
+// NewManager returns a Manager using the given client
+func NewManager(client *bastionzero.Client, opts *Options) *Manager {
+	m := &Manager{Client: client, conns: make(map[string]*entry)}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.CheckInterval <= 0 {
+		m.opts.CheckInterval = defaultCheckInterval
+	}
+	if m.opts.PollInterval <= 0 {
+		m.opts.PollInterval = defaultPollInterval
+	}
+	if m.opts.CloseTimeout <= 0 {
+		m.opts.CloseTimeout = defaultCloseTimeout
+	}
+	return m
+}
+
+// CreateShellConnection creates a shell connection and tracks it
+func (m *Manager) CreateShellConnection(ctx context.Context, request *connections.CreateShellConnectionRequest) (*connections.CreateConnectionResponse, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.Client.Connections.CreateShellConnection(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Track(resp.ConnectionID, connectiontype.Shell, request.TargetID)
+}
+
+// CreateDbConnection creates a db connection and tracks it
+func (m *Manager) CreateDbConnection(ctx context.Context, request *connections.CreateDbConnectionRequest) (*connections.CreateConnectionResponse, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.Client.Connections.CreateDbConnection(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Track(resp.ConnectionID, connectiontype.Db, request.TargetID)
+}
+
+// CreateKubeConnection creates a Kubernetes connection and tracks it
+func (m *Manager) CreateKubeConnection(ctx context.Context, request *connections.CreateKubeConnectionRequest) (*connections.CreateConnectionResponse, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.Client.Connections.CreateKubeConnection(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Track(resp.ConnectionID, connectiontype.Kube, request.TargetID)
+}
+
+// CreateWebConnection creates a web connection and tracks it
+func (m *Manager) CreateWebConnection(ctx context.Context, request *connections.CreateWebConnectionRequest) (*connections.CreateConnectionResponse, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.Client.Connections.CreateWebConnection(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Track(resp.ConnectionID, connectiontype.Web, request.TargetID)
+}
+
+// CreateUniversalConnection creates a connection to any type of target and
+// tracks it. The connection type is derived from the target type and verb of
+// the response.
+func (m *Manager) CreateUniversalConnection(ctx context.Context, request *connections.CreateUniversalConnectionRequest) (*connections.CreateUniversalConnectionResponse, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.Client.Connections.CreateUniversalConnection(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Track(resp.ConnectionID, universalConnectionType(resp.TargetType, resp.VerbType), resp.TargetId)
+}
+
+// Track starts tracking a connection created elsewhere. If the Manager was
+// shut down, the connection is closed and ErrShutdown is returned.
+func (m *Manager) Track(connectionID string, connectionType connectiontype.ConnectionType, targetID string) error {
+	now := time.Now()
+	c := Connection{ID: connectionID, Type: connectionType, TargetID: targetID, TrackedAt: now}
+	lifetime, ok := m.opts.MaxLifetime[connectionType]
+	if !ok {
+		lifetime = m.opts.DefaultMaxLifetime
+	}
+	if lifetime > 0 {
+		c.Deadline = now.Add(lifetime)
+	}
+
+	m.mu.Lock()
+	if m.shutdown {
+		m.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), m.opts.CloseTimeout)
+		defer cancel()
+		if err := m.closeConnection(ctx, connectionID); err != nil {
+			return fmt.Errorf("%w; failed to close connection %s: %v", ErrShutdown, connectionID, err)
+		}
+		return ErrShutdown
+	}
+	m.conns[connectionID] = &entry{Connection: c}
+	m.mu.Unlock()
+	return nil
+}
+
+// Untrack stops tracking a connection without closing it
+func (m *Manager) Untrack(connectionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conns, connectionID)
+}
+
+// Connections returns the tracked connections, oldest first
+func (m *Manager) Connections() []Connection {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]Connection, 0, len(m.conns))
+	for _, e := range m.conns {
+		result = append(result, e.Connection)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TrackedAt.Before(result[j].TrackedAt) })
+	return result
+}
+
+// State fetches the state of a tracked connection
+func (m *Manager) State(ctx context.Context, connectionID string) (connectionstate.ConnectionState, error) {
+	c, ok := m.lookup(connectionID)
+	if !ok {
+		return "", fmt.Errorf("connection %s is not tracked", connectionID)
+	}
+	return m.getState(ctx, &c)
+}
+
+// WaitForState polls a tracked connection until its state is desired and
+// returns the last state fetched. It returns an error when ctx is done or
+// when the connection is Closed or in Error and desired is not that state.
+// A connection found Closed is no longer tracked.
+func (m *Manager) WaitForState(ctx context.Context, connectionID string, desired connectionstate.ConnectionState) (connectionstate.ConnectionState, error) {
+	c, ok := m.lookup(connectionID)
+	if !ok {
+		return "", fmt.Errorf("connection %s is not tracked", connectionID)
+	}
+
+	ticker := time.NewTicker(m.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		state, err := m.getState(ctx, &c)
+		if err != nil {
+			return "", err
+		}
+		if state == connectionstate.Closed {
+			m.forget(c, ClosedElsewhere)
+		}
+		if state == desired {
+			return state, nil
+		}
+		if state == connectionstate.Closed || state == connectionstate.Error {
+			return state, fmt.Errorf("connection %s is %s while waiting for state %s", connectionID, state, desired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return state, fmt.Errorf("timed out waiting for connection %s to become %s (last state %s): %w", connectionID, desired, state, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes a tracked connection and stops tracking it
+func (m *Manager) Close(ctx context.Context, connectionID string) error {
+	return m.close(ctx, connectionID, Requested)
+}
+
+// Shutdown closes every tracked connection. Connections created or tracked
+// afterwards are closed immediately. If some connections fail to close, they
+// remain tracked and the first error is returned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shutdown = true
+	ids := make([]string, 0, len(m.conns))
+	for id := range m.conns {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	errs := m.closeAll(ctx, ids, Shutdown)
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close %d of %d connections: %w", len(errs), len(ids), errs[0])
+	}
+	return nil
+}
+
+// Run closes connections that exceed their maximum lifetime until ctx is
+// cancelled. It then calls Shutdown, bounded by CloseTimeout, and returns
+// ctx.Err().
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			closeCtx, cancel := context.WithTimeout(context.Background(), m.opts.CloseTimeout)
+			defer cancel()
+			if err := m.Shutdown(closeCtx); err != nil {
+				m.reportError(err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			m.enforceLifetimes(ctx)
+		}
+	}
+}
+
+func (m *Manager) enforceLifetimes(ctx context.Context) {
+	now := time.Now()
+	var expired []string
+	m.mu.Lock()
+	for id, e := range m.conns {
+		if !e.Deadline.IsZero() && now.After(e.Deadline) {
+			expired = append(expired, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, err := range m.closeAll(ctx, expired, LifetimeExceeded) {
+		m.reportError(err)
+	}
+}
+
+// closeAll closes the connections concurrently and returns the errors
+func (m *Manager) closeAll(ctx context.Context, ids []string, reason CloseReason) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, defaultMaxConcurrency)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := m.close(ctx, id, reason); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	return errs
+}
+
+// close closes a tracked connection unless another close is in flight
+func (m *Manager) close(ctx context.Context, connectionID string, reason CloseReason) error {
+	m.mu.Lock()
+	e, ok := m.conns[connectionID]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("connection %s is not tracked", connectionID)
+	}
+	if e.closing {
+		m.mu.Unlock()
+		return nil
+	}
+	e.closing = true
+	c := e.Connection
+	m.mu.Unlock()
+
+	if err := m.closeConnection(ctx, connectionID); err != nil {
+		m.mu.Lock()
+		e.closing = false
+		m.mu.Unlock()
+		return fmt.Errorf("failed to close connection %s: %w", connectionID, err)
+	}
+	m.forget(c, reason)
+	return nil
+}
+
+// closeConnection closes a connection. Connections that no longer exist are
+// considered closed.
+func (m *Manager) closeConnection(ctx context.Context, connectionID string) error {
+	_, err := m.Client.Connections.CloseConnection(ctx, connectionID)
+	if err != nil && !apierror.IsAPIErrorStatusCode(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
+}
+
+// forget stops tracking a closed connection and calls OnClose
+func (m *Manager) forget(c Connection, reason CloseReason) {
+	m.mu.Lock()
+	_, ok := m.conns[c.ID]
+	delete(m.conns, c.ID)
+	m.mu.Unlock()
+
+	if ok && m.opts.OnClose != nil {
+		m.opts.OnClose(c, reason)
+	}
+}
+
+func (m *Manager) lookup(connectionID string) (Connection, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.conns[connectionID]
+	if !ok {
+		return Connection{}, false
+	}
+	return e.Connection, true
+}
+
+func (m *Manager) checkOpen() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shutdown {
+		return ErrShutdown
+	}
+	return nil
+}
+
+// getState fetches the state of a connection from the endpoint of its type
+func (m *Manager) getState(ctx context.Context, c *Connection) (connectionstate.ConnectionState, error) {
+	var (
+		conn connections.ConnectionInterface
+		err  error
+	)
+	s := m.Client.Connections
+	switch c.Type {
+	case connectiontype.Shell:
+		conn, _, err = s.GetShellConnection(ctx, c.ID)
+	case connectiontype.Db:
+		conn, _, err = s.GetDbConnection(ctx, c.ID)
+	case connectiontype.Kube:
+		conn, _, err = s.GetKubeConnection(ctx, c.ID)
+	case connectiontype.Web:
+		conn, _, err = s.GetWebConnection(ctx, c.ID)
+	case connectiontype.Ssh:
+		conn, _, err = s.GetSSHConnection(ctx, c.ID)
+	case connectiontype.Rdp:
+		conn, _, err = s.GetRDPConnection(ctx, c.ID)
+	case connectiontype.SqlServer:
+		conn, _, err = s.GetSQLServerConnection(ctx, c.ID)
+	case connectiontype.Dynamic:
+		dynamic, _, err := s.GetDynamicAccessConnection(ctx, c.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get connection %s: %w", c.ID, err)
+		}
+		return dynamic.ConnectionState, nil
+	default:
+		return "", fmt.Errorf("unsupported connection type %s", c.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get connection %s: %w", c.ID, err)
+	}
+	return conn.GetState(), nil
+}
+
+// universalConnectionType returns the type of a connection created by
+// CreateUniversalConnection
+func universalConnectionType(targetType targettype.TargetType, verb verbtype.VerbType) connectiontype.ConnectionType {
+	switch targetType {
+	case targettype.Cluster:
+		return connectiontype.Kube
+	case targettype.Db:
+		return connectiontype.Db
+	case targettype.Web:
+		return connectiontype.Web
+	case targettype.DynamicAccessConfig:
+		return connectiontype.Dynamic
+	}
+	switch verb {
+	case verbtype.Tunnel, verbtype.FileTransfer:
+		return connectiontype.Ssh
+	case verbtype.RDP:
+		return connectiontype.Rdp
+	case verbtype.SQLServer:
+		return connectiontype.SqlServer
+	default:
+		return connectiontype.Shell
+	}
+}
+
+func (m *Manager) reportError(err error) {
+	if m.opts.OnError != nil {
+		m.opts.OnError(err)
+	}
+}
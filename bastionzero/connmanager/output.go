@@ -0,0 +1,44 @@
+package connmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteJSON writes the report to w as indented JSON
+func (r *TerminateReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report to w as a human readable table with one row
+// per connection, followed by the list errors (see TerminateReport.ListErrors)
+// and a summary
+func (r *TerminateReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONNECTION\tTYPE\tTARGET\tSUBJECT\tCREATED\tSTATUS\tERROR")
+	for _, result := range r.Results {
+		c := result.Connection
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Type, c.TargetID, c.SubjectID, c.TimeCreated.UTC().Format(time.RFC3339), result.Status, result.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.ListErrors) > 0 {
+		fmt.Fprintln(w)
+		for _, e := range r.ListErrors {
+			fmt.Fprintln(w, e.Error)
+		}
+	}
+
+	if r.DryRun {
+		_, err := fmt.Fprintf(w, "\ndry run: %d connection(s) would be terminated\n", r.Count(WouldTerminate))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d connection(s): %d terminated, %d failed\n", len(r.Results), r.Count(Terminated), r.Count(TerminateFailed))
+	return err
+}
@@ -0,0 +1,376 @@
+package connmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/inventory"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections/connectionstate"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections/connectiontype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// ListableTypes are the connection types ListOpenConnections can list.
+// Dynamic access connections cannot be listed.
+var ListableTypes = []connectiontype.ConnectionType{
+	connectiontype.Shell,
+	connectiontype.Ssh,
+	connectiontype.Db,
+	connectiontype.Kube,
+	connectiontype.Web,
+	connectiontype.Rdp,
+	connectiontype.SqlServer,
+}
+
+// OpenConnection is an open connection of any type
+type OpenConnection struct {
+	connections.Connection
+	Type connectiontype.ConnectionType `json:"type"`
+	// EnvironmentID is the environment of the target connected to. It is
+	// only set when filtering by environment.
+	EnvironmentID string `json:"environmentId,omitempty"`
+}
+
+// Filter selects open connections. Empty fields match every connection.
+type Filter struct {
+	TargetIDs      []string
+	EnvironmentIDs []string
+	SubjectIDs     []string
+	// Types defaults to ListableTypes if empty
+	Types []connectiontype.ConnectionType
+	// OlderThan matches connections created more than this long ago
+	OlderThan time.Duration
+}
+
+// Matches returns true if the connection matches the filter at time now
+func (f *Filter) Matches(c *OpenConnection, now time.Time) bool {
+	return matches(f.TargetIDs, c.TargetID) &&
+		matches(f.EnvironmentIDs, c.EnvironmentID) &&
+		matches(f.SubjectIDs, c.SubjectID) &&
+		matches(f.Types, c.Type) &&
+		(f.OlderThan <= 0 || now.Sub(c.TimeCreated.Time) > f.OlderThan)
+}
+
+// ListError records a connection type that could not be listed, or a
+// connection that could not be matched against Filter.EnvironmentIDs because
+// the environment of its target is unknown
+type ListError struct {
+	Type connectiontype.ConnectionType `json:"type"`
+	// ConnectionID and TargetID are set for a connection whose environment
+	// is unknown
+	ConnectionID string `json:"connectionId,omitempty"`
+	TargetID     string `json:"targetId,omitempty"`
+	Error        string `json:"error"`
+}
+
+// ListOpenConnections lists the open connections of every type in
+// filter.Types concurrently and returns those matching the filter, oldest
+// first. If filter.EnvironmentIDs is set, the targets are fetched to find the
+// environment of each connection.
+//
+// A type that cannot be listed does not prevent the others from being
+// returned; it is reported in the returned ListErrors, in the order of
+// filter.Types. When filtering by environment, a connection whose target is
+// missing from the fetched targets (e.g. because it was deleted, is not
+// visible to the caller, or its type of target could not be listed) is not
+// returned but reported in ListErrors as well, after the types. An error is
+// only returned if no connection type or no target type could be listed.
+func ListOpenConnections(ctx context.Context, client *bastionzero.Client, filter *Filter) ([]OpenConnection, []ListError, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+	types := filter.Types
+	if len(types) == 0 {
+		types = ListableTypes
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		all  []OpenConnection
+		errs = make([]error, len(types))
+	)
+	for i, t := range types {
+		wg.Add(1)
+		go func(i int, t connectiontype.ConnectionType) {
+			defer wg.Done()
+			list, err := listOpen(ctx, client, t)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to list %s connections: %w", t, err)
+				return
+			}
+			all = append(all, list...)
+		}(i, t)
+	}
+	wg.Wait()
+
+	var listErrors []ListError
+	for i, err := range errs {
+		if err != nil {
+			listErrors = append(listErrors, ListError{Type: types[i], Error: err.Error()})
+		}
+	}
+	if len(listErrors) == len(types) {
+		return nil, listErrors, errs[0]
+	}
+
+	now := time.Now()
+	if len(filter.EnvironmentIDs) > 0 {
+		inv, fetchErrs, err := fetchTargets(ctx, client)
+		if err != nil {
+			return nil, listErrors, err
+		}
+
+		// Report the connections that match every other criterion but
+		// cannot be matched by environment
+		withoutEnvironment := *filter
+		withoutEnvironment.EnvironmentIDs = nil
+		sort.Slice(all, func(i, j int) bool { return all[i].TimeCreated.Before(all[j].TimeCreated.Time) })
+		for i := range all {
+			c := &all[i]
+			if t := inv.ByID(c.TargetID); t != nil {
+				c.EnvironmentID = t.GetEnvironmentID()
+				continue
+			}
+			if !withoutEnvironment.Matches(c, now) {
+				continue
+			}
+			reason := "target not found"
+			if err, ok := fetchErrs[c.TargetType]; ok {
+				reason = err.Error()
+			}
+			listErrors = append(listErrors, ListError{
+				Type:         c.Type,
+				ConnectionID: c.ID,
+				TargetID:     c.TargetID,
+				Error:        fmt.Sprintf("environment of connection %s to target %s is unknown (%s)", c.ID, c.TargetID, reason),
+			})
+		}
+	}
+
+	var result []OpenConnection
+	for i := range all {
+		if filter.Matches(&all[i], now) {
+			result = append(result, all[i])
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TimeCreated.Before(result[j].TimeCreated.Time) })
+	return result, listErrors, nil
+}
+
+// fetchTargets fetches the targets of every type concurrently. A type that
+// cannot be listed is returned in the map of errors instead of failing the
+// others; an error is only returned if no type could be listed.
+func fetchTargets(ctx context.Context, client *bastionzero.Client) (*inventory.Inventory, map[targettype.TargetType]error, error) {
+	types := targettype.TargetTypeValues()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		all  []targets.TargetInterface
+		errs = make(map[targettype.TargetType]error)
+	)
+	for _, t := range types {
+		wg.Add(1)
+		go func(t targettype.TargetType) {
+			defer wg.Done()
+			inv, err := inventory.Fetch(ctx, client, &inventory.FetchOptions{Types: []targettype.TargetType{t}})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[t] = err
+				return
+			}
+			all = append(all, inv.All()...)
+		}(t)
+	}
+	wg.Wait()
+
+	if len(errs) == len(types) {
+		return nil, errs, fmt.Errorf("failed to fetch targets: %w", errs[types[0]])
+	}
+	return inventory.New(all, nil), errs, nil
+}
+
+// listOpen lists the open connections of one type
+func listOpen(ctx context.Context, client *bastionzero.Client, t connectiontype.ConnectionType) ([]OpenConnection, error) {
+	opts := &connections.ListConnectionOptions{ConnectionState: connectionstate.Open}
+	s := client.Connections
+	switch t {
+	case connectiontype.Shell:
+		list, _, err := s.ListShellConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.ShellConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.Ssh:
+		list, _, err := s.ListSSHConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.SSHConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.Db:
+		list, _, err := s.ListDbConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.DbConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.Kube:
+		list, _, err := s.ListKubeConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.KubeConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.Web:
+		list, _, err := s.ListWebConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.WebConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.Rdp:
+		list, _, err := s.ListRDPConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.RDPConnection) connections.Connection { return c.Connection }), err
+	case connectiontype.SqlServer:
+		list, _, err := s.ListSQLServerConnections(ctx, opts)
+		return toOpen(list, t, func(c *connections.SQLServerConnection) connections.Connection { return c.Connection }), err
+	default:
+		return nil, fmt.Errorf("connections of type %s cannot be listed", t)
+	}
+}
+
+func toOpen[T any](list []T, t connectiontype.ConnectionType, common func(*T) connections.Connection) []OpenConnection {
+	result := make([]OpenConnection, 0, len(list))
+	for i := range list {
+		c := common(&list[i])
+		// The state filter is applied by the server, but double check it so
+		// that closed connections are never reported as terminated
+		if c.State == connectionstate.Open {
+			result = append(result, OpenConnection{Connection: c, Type: t})
+		}
+	}
+	return result
+}
+
+// TerminateStatus is the outcome of terminating a single connection
+type TerminateStatus string
+
+const (
+	// Terminated means the connection was closed
+	Terminated TerminateStatus = "Terminated"
+	// TerminateFailed means closing the connection failed
+	TerminateFailed TerminateStatus = "Failed"
+	// WouldTerminate means the connection matched in a dry run
+	WouldTerminate TerminateStatus = "WouldTerminate"
+)
+
+// TerminateOptions specifies the optional parameters to TerminateConnections
+type TerminateOptions struct {
+	Filter Filter
+	// DryRun lists the matching connections without closing them
+	DryRun bool
+	// MaxConcurrency is the maximum number of connections closed at the same
+	// time. Defaults to 10.
+	MaxConcurrency int
+	// OnResult is called with the result of each connection as soon as it is
+	// known. It may be called concurrently.
+	OnResult func(TerminateResult)
+}
+
+// TerminateResult is the outcome of terminating a single connection
+type TerminateResult struct {
+	Connection OpenConnection  `json:"connection"`
+	Status     TerminateStatus `json:"status"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// TerminateReport is the outcome of TerminateConnections
+type TerminateReport struct {
+	StartedAt time.Time         `json:"startedAt"`
+	DryRun    bool              `json:"dryRun"`
+	Results   []TerminateResult `json:"results"`
+	// ListErrors lists the connection types that could not be listed and,
+	// when filtering by environment, the connections whose environment is
+	// unknown. None of them were terminated.
+	ListErrors []ListError `json:"listErrors,omitempty"`
+}
+
+// Count returns the number of results with the given status
+func (r *TerminateReport) Count(status TerminateStatus) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// TerminateConnections closes the open connections matching opts.Filter
+// concurrently. Unlike UsersService.CloseUserConnections and
+// SubjectsService.CloseSubjectConnections it can target every connection to
+// a target or environment. Connections that no longer exist count as
+// terminated.
+//
+// The report lists the matching connections in the order listed by
+// ListOpenConnections, and the connection types that could not be listed in
+// ListErrors. An error is returned if no connection type can be listed or ctx
+// is cancelled; in the latter case the connections not yet closed are
+// reported as failed.
+func TerminateConnections(ctx context.Context, client *bastionzero.Client, opts *TerminateOptions) (*TerminateReport, error) {
+	if opts == nil {
+		opts = &TerminateOptions{}
+	}
+	maxConcurrency := opts.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+
+	report := &TerminateReport{StartedAt: time.Now(), DryRun: opts.DryRun}
+	list, listErrors, err := ListOpenConnections(ctx, client, &opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	report.ListErrors = listErrors
+
+	report.Results = make([]TerminateResult, len(list))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+	for i := range list {
+		result := &report.Results[i]
+		result.Connection = list[i]
+		if opts.DryRun {
+			result.Status = WouldTerminate
+			if opts.OnResult != nil {
+				opts.OnResult(*result)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result.Status = Terminated
+			_, err := client.Connections.CloseConnection(ctx, result.Connection.ID)
+			if err != nil && !apierror.IsAPIErrorStatusCode(err, http.StatusNotFound) {
+				result.Status = TerminateFailed
+				result.Error = err.Error()
+			}
+			if opts.OnResult != nil {
+				opts.OnResult(*result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report, ctx.Err()
+}
+
+func matches[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
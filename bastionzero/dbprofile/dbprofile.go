@@ -0,0 +1,273 @@
+// Package dbprofile generates database client configuration for the
+// database targets accessible through BastionZero.
+//
+// Clients connect to a database target through the local daemon listening on
+// the target's LocalHost and LocalPort. How they authenticate depends on the
+// target's DatabaseAuthenticationConfig:
+//
+//   - Default: the connection is proxied as is, so the client authenticates
+//     to the database itself (e.g. with a password) and negotiates TLS with it.
+//   - SplitCert and ServiceAccountInjection: the agent authenticates to the
+//     database and encrypts the connection on the client's behalf. The client
+//     connects as one of the target's allowed target users, without a password
+//     and without TLS to the local daemon.
+//
+// Generate returns one Profile per target and user. The Write functions and
+// MongoURI and SQLServerConnectionString format the profiles for each engine:
+// pg_service.conf and .pgpass for Postgres and CockroachDB, my.cnf option
+// groups for MySQL, connection URIs for MongoDB and connection strings for
+// SQL Server.
+package dbprofile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dbauthconfig"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/internal"
+)
+
+const defaultLocalHost = "localhost"
+
+// Target is a database target
+type Target struct {
+	ID              string
+	Name            string
+	EnvironmentName string
+	LocalHost       string
+	// LocalPort is nil if the target has no fixed local port
+	LocalPort *int
+	Users     []string
+	Config    dbauthconfig.DatabaseAuthenticationConfig
+	// IsSplitCert and DatabaseType are the deprecated fields used when Config
+	// does not specify the authentication type or database
+	IsSplitCert  bool
+	DatabaseType string
+}
+
+// Profile is the configuration of a client connecting to a target as one
+// user
+type Profile struct {
+	// Name identifies the profile, e.g. as a pg_service.conf section. It is
+	// "<environment>-<target>-<user>", or "<environment>-<target>" without a
+	// user.
+	Name               string
+	TargetID           string
+	TargetName         string
+	Database           string
+	AuthenticationType string
+	Host               string
+	Port               int
+	// User is empty for Default authentication targets without allowed
+	// target users
+	User string
+	// DatabaseName is the database to connect to, if known
+	DatabaseName string
+}
+
+// AgentAuthenticated returns true if the agent authenticates to the database
+// on the client's behalf
+func (p *Profile) AgentAuthenticated() bool {
+	return p.AuthenticationType == dbauthconfig.SplitCert || p.AuthenticationType == dbauthconfig.ServiceAccountInjection
+}
+
+// Skipped is a target no profile was generated for
+type Skipped struct {
+	TargetID   string
+	TargetName string
+	Reason     string
+}
+
+// Options specifies the optional parameters to Generate
+type Options struct {
+	// DatabaseName returns the database to connect to on a target. If nil or
+	// empty, the client's default is used.
+	DatabaseName func(t *Target) string
+}
+
+// Result is the output of Generate
+type Result struct {
+	Profiles []Profile
+	Skipped  []Skipped
+}
+
+// FromAllTargets returns the database targets of a ListAllTargets response
+func FromAllTargets(all *targets_disambiguated.AllTargetsResponse) []Target {
+	result := make([]Target, len(all.Db))
+	for i, t := range all.Db {
+		result[i] = Target{
+			ID:              t.ID,
+			Name:            t.Name,
+			EnvironmentName: t.EnvironmentName,
+			LocalHost:       t.LocalHost,
+			Users:           usernames(t.AllowedTargetUsers),
+			Config:          t.DatabaseAuthenticationConfig,
+		}
+		if t.LocalPort != nil {
+			result[i].LocalPort = t.LocalPort.Value
+		}
+	}
+	return result
+}
+
+// FromDatabaseTargets converts database targets. environmentNames maps
+// environment IDs to names; the ID is used for environments missing from it.
+func FromDatabaseTargets(list []targets.DatabaseTarget, environmentNames map[string]string) []Target {
+	result := make([]Target, len(list))
+	for i, t := range list {
+		environment, ok := environmentNames[t.EnvironmentID]
+		if !ok {
+			environment = t.EnvironmentID
+		}
+		result[i] = Target{
+			ID:              t.ID,
+			Name:            t.Name,
+			EnvironmentName: environment,
+			LocalHost:       t.LocalHost,
+			LocalPort:       t.LocalPort.Value,
+			Users:           usernames(t.AllowedTargetUsers),
+			Config:          t.DatabaseAuthenticationConfig,
+			IsSplitCert:     t.IsSplitCert,
+		}
+		if t.DatabaseType != nil {
+			result[i].DatabaseType = *t.DatabaseType
+		}
+	}
+	return result
+}
+
+// Fetch lists the database targets accessible to the client and generates
+// profiles for them
+func Fetch(ctx context.Context, client *bastionzero.Client, opts *Options) (*Result, error) {
+	all, _, err := client.AllTargets.ListAllTargets(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	return Generate(FromAllTargets(all), opts), nil
+}
+
+// Generate returns one profile per target and allowed target user, sorted by
+// name. Targets without a local port, with an unknown database, or using
+// agent authentication without allowed target users are skipped.
+func Generate(list []Target, opts *Options) *Result {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	result := &Result{}
+	for i := range list {
+		t := &list[i]
+		skip := func(format string, args ...interface{}) {
+			result.Skipped = append(result.Skipped, Skipped{TargetID: t.ID, TargetName: t.Name, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		database := databaseOf(t)
+		if database == "" {
+			skip("unknown database")
+			continue
+		}
+		if t.LocalPort == nil {
+			skip("no local port; the port is chosen when connecting")
+			continue
+		}
+
+		base := Profile{
+			TargetID:           t.ID,
+			TargetName:         t.Name,
+			Database:           database,
+			AuthenticationType: authenticationTypeOf(t),
+			Host:               t.LocalHost,
+			Port:               *t.LocalPort,
+		}
+		if base.Host == "" {
+			base.Host = defaultLocalHost
+		}
+		if opts.DatabaseName != nil {
+			base.DatabaseName = opts.DatabaseName(t)
+		}
+
+		users := t.Users
+		if len(users) == 0 {
+			if base.AgentAuthenticated() {
+				skip("%s authentication requires an allowed target user", base.AuthenticationType)
+				continue
+			}
+			users = []string{""}
+		}
+		for _, user := range users {
+			p := base
+			p.User = user
+			p.Name = profileName(t.EnvironmentName, t.Name, user)
+			result.Profiles = append(result.Profiles, p)
+		}
+	}
+
+	sort.Slice(result.Profiles, func(i, j int) bool { return result.Profiles[i].Name < result.Profiles[j].Name })
+	return result
+}
+
+// ForDatabase returns the profiles for the given databases (e.g.
+// dbauthconfig.Postgres)
+func (r *Result) ForDatabase(databases ...string) []Profile {
+	var result []Profile
+	for _, p := range r.Profiles {
+		for _, d := range databases {
+			if p.Database == d {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// databaseOf returns the target's database, falling back to the deprecated
+// DatabaseType. Returns an empty string if unknown.
+func databaseOf(t *Target) string {
+	if t.Config.Database != nil {
+		return *t.Config.Database
+	}
+	for _, d := range []string{dbauthconfig.CockroachDB, dbauthconfig.MicrosoftSQLServer, dbauthconfig.MongoDB, dbauthconfig.MySQL, dbauthconfig.Postgres} {
+		if strings.EqualFold(t.DatabaseType, d) {
+			return d
+		}
+	}
+	return ""
+}
+
+// authenticationTypeOf returns the target's authentication type, falling back
+// to the deprecated IsSplitCert
+func authenticationTypeOf(t *Target) string {
+	if t.Config.AuthenticationType != nil {
+		return *t.Config.AuthenticationType
+	}
+	if t.IsSplitCert {
+		return dbauthconfig.SplitCert
+	}
+	return dbauthconfig.Default
+}
+
+func profileName(environment, target, user string) string {
+	parts := []string{environment, target}
+	if user != "" {
+		parts = append(parts, user)
+	}
+	for i, p := range parts {
+		parts[i] = internal.SanitizeName(p)
+	}
+	return strings.Join(parts, "-")
+}
+
+func usernames(users []policies.TargetUser) []string {
+	result := make([]string, len(users))
+	for i, u := range users {
+		result[i] = u.Username
+	}
+	return result
+}
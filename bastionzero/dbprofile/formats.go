@@ -0,0 +1,166 @@
+package dbprofile
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dbauthconfig"
+)
+
+// WritePGService writes a pg_service.conf section for each Postgres and
+// CockroachDB profile. Profiles using agent authentication disable TLS to the
+// local daemon.
+func WritePGService(w io.Writer, profiles []Profile) error {
+	var b strings.Builder
+	for _, p := range pgProfiles(profiles) {
+		fmt.Fprintf(&b, "[%s]\n", p.Name)
+		fmt.Fprintf(&b, "host=%s\n", p.Host)
+		fmt.Fprintf(&b, "port=%d\n", p.Port)
+		if p.User != "" {
+			fmt.Fprintf(&b, "user=%s\n", p.User)
+		}
+		if p.DatabaseName != "" {
+			fmt.Fprintf(&b, "dbname=%s\n", p.DatabaseName)
+		}
+		if p.AgentAuthenticated() {
+			b.WriteString("sslmode=disable\n")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WritePGPass writes a .pgpass line for each Postgres and CockroachDB profile
+// using Default authentication and a user. password returns the password of
+// a profile; profiles it returns an empty password for are omitted. Profiles
+// using agent authentication need no password and are always omitted.
+func WritePGPass(w io.Writer, profiles []Profile, password func(p *Profile) string) error {
+	var b strings.Builder
+	for _, p := range pgProfiles(profiles) {
+		if p.AgentAuthenticated() || p.User == "" {
+			continue
+		}
+		pw := password(&p)
+		if pw == "" {
+			continue
+		}
+		database := p.DatabaseName
+		if database == "" {
+			database = "*"
+		}
+		fmt.Fprintf(&b, "%s:%d:%s:%s:%s\n", pgpassEscape(p.Host), p.Port, pgpassEscape(database), pgpassEscape(p.User), pgpassEscape(pw))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMyCnf writes a my.cnf option group named "client-<profile name>" for
+// each MySQL profile. Select one with mysql --defaults-group-suffix.
+func WriteMyCnf(w io.Writer, profiles []Profile) error {
+	var b strings.Builder
+	for _, p := range profiles {
+		if p.Database != dbauthconfig.MySQL {
+			continue
+		}
+		fmt.Fprintf(&b, "[client-%s]\n", p.Name)
+		// Use TCP; "localhost" would make the client use a Unix socket
+		fmt.Fprintf(&b, "protocol=TCP\n")
+		fmt.Fprintf(&b, "host=%s\n", p.Host)
+		fmt.Fprintf(&b, "port=%d\n", p.Port)
+		if p.User != "" {
+			fmt.Fprintf(&b, "user=%s\n", p.User)
+		}
+		if p.DatabaseName != "" {
+			fmt.Fprintf(&b, "database=%s\n", p.DatabaseName)
+		}
+		if p.AgentAuthenticated() {
+			b.WriteString("ssl-mode=DISABLED\n")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// MongoURI returns the connection URI of a MongoDB profile. With Default
+// authentication the user is included and the client supplies the password;
+// with agent authentication no credentials are included.
+func MongoURI(p *Profile) string {
+	u := url.URL{
+		Scheme: "mongodb",
+		Host:   p.Host + ":" + strconv.Itoa(p.Port),
+		Path:   "/" + p.DatabaseName,
+	}
+	query := url.Values{}
+	query.Set("directConnection", "true")
+	if p.AgentAuthenticated() {
+		query.Set("tls", "false")
+	} else if p.User != "" {
+		u.User = url.User(p.User)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// SQLServerConnectionString returns the ADO.NET style connection string of a
+// SQL Server profile. With agent authentication encryption to the local
+// daemon is disabled and no password is needed.
+func SQLServerConnectionString(p *Profile) string {
+	parts := []string{fmt.Sprintf("Server=tcp:%s,%d", p.Host, p.Port)}
+	if p.DatabaseName != "" {
+		parts = append(parts, "Database="+sqlServerQuote(p.DatabaseName))
+	}
+	if p.User != "" {
+		parts = append(parts, "User ID="+sqlServerQuote(p.User))
+	}
+	if p.AgentAuthenticated() {
+		parts = append(parts, "Encrypt=false")
+	}
+	return strings.Join(parts, ";") + ";"
+}
+
+// WriteURIs writes "<profile name> <URI or connection string>" lines for
+// each MongoDB and SQL Server profile
+func WriteURIs(w io.Writer, profiles []Profile) error {
+	var b strings.Builder
+	for i := range profiles {
+		p := &profiles[i]
+		switch p.Database {
+		case dbauthconfig.MongoDB:
+			fmt.Fprintf(&b, "%s %s\n", p.Name, MongoURI(p))
+		case dbauthconfig.MicrosoftSQLServer:
+			fmt.Fprintf(&b, "%s %s\n", p.Name, SQLServerConnectionString(p))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func pgProfiles(profiles []Profile) []Profile {
+	var result []Profile
+	for _, p := range profiles {
+		if p.Database == dbauthconfig.Postgres || p.Database == dbauthconfig.CockroachDB {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+var pgpassEscaper = strings.NewReplacer(`\`, `\\`, `:`, `\:`)
+
+func pgpassEscape(s string) string {
+	return pgpassEscaper.Replace(s)
+}
+
+// sqlServerQuote quotes a connection string value if it contains characters
+// with special meaning
+func sqlServerQuote(s string) string {
+	if !strings.ContainsAny(s, ";'\"{}= ") {
+		return s
+	}
+	return "{" + strings.ReplaceAll(s, "}", "}}") + "}"
+}
@@ -0,0 +1,123 @@
+package decoding
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// EnumSighting aggregates occurrences of an unknown enum value
+type EnumSighting struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	// Count is the number of times the value was seen
+	Count int `json:"count"`
+	// Endpoints lists the endpoints (method and path) the value was seen in
+	Endpoints []string `json:"endpoints"`
+}
+
+// FieldSighting aggregates occurrences of an unknown field
+type FieldSighting struct {
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	// Count is the number of times the field was seen
+	Count int `json:"count"`
+	// Endpoints lists the endpoints (method and path) the field was seen in
+	Endpoints []string `json:"endpoints"`
+}
+
+// Collector aggregates reports across API calls. It is safe for concurrent
+// use. Pass its Record method to bastionzero.WithStrictDecoding.
+type Collector struct {
+	mu     sync.Mutex
+	enums  map[[2]string]*EnumSighting
+	fields map[[2]string]*FieldSighting
+}
+
+// NewCollector returns an empty Collector
+func NewCollector() *Collector {
+	return &Collector{
+		enums:  make(map[[2]string]*EnumSighting),
+		fields: make(map[[2]string]*FieldSighting),
+	}
+}
+
+// Record adds report, found in the response to req, to the collector
+func (c *Collector) Record(req *http.Request, report *Report) {
+	endpoint := req.Method + " " + req.URL.Path
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range report.UnknownEnumValues {
+		key := [2]string{e.Type, e.Value}
+		s, ok := c.enums[key]
+		if !ok {
+			s = &EnumSighting{Type: e.Type, Value: e.Value}
+			c.enums[key] = s
+		}
+		s.Count++
+		s.Endpoints = addEndpoint(s.Endpoints, endpoint)
+	}
+	for _, f := range report.UnknownFields {
+		key := [2]string{f.Type, f.Field}
+		s, ok := c.fields[key]
+		if !ok {
+			s = &FieldSighting{Type: f.Type, Field: f.Field}
+			c.fields[key] = s
+		}
+		s.Count++
+		s.Endpoints = addEndpoint(s.Endpoints, endpoint)
+	}
+}
+
+// UnknownEnumValues returns the unknown enum values seen so far, sorted by
+// type and value
+func (c *Collector) UnknownEnumValues() []EnumSighting {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]EnumSighting, 0, len(c.enums))
+	for _, s := range c.enums {
+		sighting := *s
+		sighting.Endpoints = append([]string{}, s.Endpoints...)
+		result = append(result, sighting)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+// UnknownFields returns the unknown fields seen so far, sorted by type and
+// field
+func (c *Collector) UnknownFields() []FieldSighting {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]FieldSighting, 0, len(c.fields))
+	for _, s := range c.fields {
+		sighting := *s
+		sighting.Endpoints = append([]string{}, s.Endpoints...)
+		result = append(result, sighting)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Field < result[j].Field
+	})
+	return result
+}
+
+func addEndpoint(endpoints []string, endpoint string) []string {
+	for _, e := range endpoints {
+		if e == endpoint {
+			return endpoints
+		}
+	}
+	return append(endpoints, endpoint)
+}
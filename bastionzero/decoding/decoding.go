@@ -0,0 +1,239 @@
+// Package decoding finds values in BastionZero API responses that this SDK
+// does not know about yet.
+//
+// The SDK's enum types (e.g. targettype.TargetType) accept any string when
+// decoded, and encoding/json silently drops JSON fields that have no
+// corresponding struct field. Unmarshal decodes a response like
+// json.Unmarshal and additionally returns a Report listing each unknown enum
+// value and unknown field by path. Unknown values never cause decoding to
+// fail.
+//
+// To inspect every response made by a client, pass a hook to
+// bastionzero.WithStrictDecoding. A Collector is a ready-made hook that
+// aggregates what it sees across calls.
+//
+// Parse converts a string to any of the SDK's enum types, rejecting values the
+// SDK does not know.
+package decoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Enum is implemented by the SDK's generated enum types
+type Enum interface {
+	Valid() bool
+}
+
+// UnknownEnumValue is an enum value that is not one of the values known to
+// the SDK
+type UnknownEnumValue struct {
+	// Path is the location of the value in the response, e.g.
+	// "[2].targetUsers[0]" or "status"
+	Path string `json:"path"`
+	// Type is the Go type of the enum, e.g. "targettype.TargetType"
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// UnknownField is a JSON field that has no corresponding struct field
+type UnknownField struct {
+	// Path is the location of the field in the response
+	Path string `json:"path"`
+	// Type is the Go type of the struct the field was found in
+	Type string `json:"type"`
+	// Field is the JSON name of the field
+	Field string `json:"field"`
+}
+
+// Report lists the values of a response that the SDK does not know about
+type Report struct {
+	UnknownEnumValues []UnknownEnumValue `json:"unknownEnumValues"`
+	UnknownFields     []UnknownField     `json:"unknownFields"`
+}
+
+// Empty returns true if nothing unknown was found
+func (r *Report) Empty() bool {
+	return len(r.UnknownEnumValues) == 0 && len(r.UnknownFields) == 0
+}
+
+// Unmarshal decodes data into v like json.Unmarshal and returns a report of
+// the unknown enum values and fields found. The report is nil if decoding
+// fails.
+func Unmarshal(data []byte, v interface{}) (*Report, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return Inspect(data, v)
+}
+
+// Inspect compares data with v, the value data was decoded into, and returns
+// a report of the unknown enum values and fields found
+func Inspect(data []byte, v interface{}) (*Report, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	report := &Report{UnknownEnumValues: []UnknownEnumValue{}, UnknownFields: []UnknownField{}}
+	walk("", raw, reflect.ValueOf(v), report)
+
+	sort.Slice(report.UnknownEnumValues, func(i, j int) bool {
+		return report.UnknownEnumValues[i].Path < report.UnknownEnumValues[j].Path
+	})
+	sort.Slice(report.UnknownFields, func(i, j int) bool {
+		return report.UnknownFields[i].Path < report.UnknownFields[j].Path
+	})
+	return report, nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func walk(path string, raw interface{}, v reflect.Value, report *Report) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if raw == nil {
+		return
+	}
+
+	if v.Kind() == reflect.String {
+		if e, ok := v.Interface().(Enum); ok && v.String() != "" && !e.Valid() {
+			report.UnknownEnumValues = append(report.UnknownEnumValues, UnknownEnumValue{
+				Path:  path,
+				Type:  v.Type().String(),
+				Value: v.String(),
+			})
+		}
+		return
+	}
+
+	// Types that decode themselves (e.g. types.Timestamp) do not necessarily
+	// map JSON fields to struct fields
+	if reflect.PtrTo(v.Type()).Implements(unmarshalerType) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(v.Type())
+		for key, rawValue := range obj {
+			index, ok := lookupField(fields, key)
+			if !ok {
+				report.UnknownFields = append(report.UnknownFields, UnknownField{
+					Path:  joinPath(path, key),
+					Type:  v.Type().String(),
+					Field: key,
+				})
+				continue
+			}
+			if fv, ok := fieldByIndex(v, index); ok {
+				walk(joinPath(path, key), rawValue, fv, report)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i := 0; i < v.Len() && i < len(list); i++ {
+			walk(fmt.Sprintf("%s[%d]", path, i), list[i], v.Index(i), report)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for key, rawValue := range obj {
+			mv := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			if mv.IsValid() {
+				walk(joinPath(path, key), rawValue, mv, report)
+			}
+		}
+	}
+}
+
+// jsonFields returns the JSON names of the fields of struct type t (including
+// fields promoted from embedded structs) mapped to their index
+func jsonFields(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	var collect func(t reflect.Type, prefix []int)
+	collect = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name := strings.Split(tag, ",")[0]
+			index := append(append([]int{}, prefix...), i)
+
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				collect(ft, index)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			// Fields of the outer struct take precedence over promoted fields
+			if _, ok := fields[name]; !ok || len(fields[name]) > len(index) {
+				fields[name] = index
+			}
+		}
+	}
+	collect(t, nil)
+	return fields
+}
+
+// lookupField matches key like encoding/json: exactly, then case-insensitively
+func lookupField(fields map[string][]int, key string) ([]int, bool) {
+	if index, ok := fields[key]; ok {
+		return index, true
+	}
+	for name, index := range fields {
+		if strings.EqualFold(name, key) {
+			return index, true
+		}
+	}
+	return nil, false
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but returns false instead of
+// panicking when an embedded pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,19 @@
+package decoding
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Parse converts s to the enum type T, e.g. Parse[targettype.TargetType]("Bzero").
+// It returns an error if s is not one of the values known to the SDK.
+func Parse[T interface {
+	~string
+	Enum
+}](s string) (T, error) {
+	v := T(s)
+	if !v.Valid() {
+		return v, fmt.Errorf("unknown %s %q", reflect.TypeOf(v), s)
+	}
+	return v, nil
+}
@@ -0,0 +1,149 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/snapshot"
+)
+
+// Sink receives drift reports from a Detector
+type Sink interface {
+	Notify(ctx context.Context, report *Report) error
+}
+
+// SinkFunc adapts an ordinary function to the Sink interface
+type SinkFunc func(ctx context.Context, report *Report) error
+
+// Notify calls f(ctx, report)
+func (f SinkFunc) Notify(ctx context.Context, report *Report) error {
+	return f(ctx, report)
+}
+
+// WriterSink writes each report to W as indented JSON
+type WriterSink struct {
+	W io.Writer
+}
+
+// Notify writes the report to the sink's writer
+func (s *WriterSink) Notify(ctx context.Context, report *Report) error {
+	return report.WriteJSON(s.W)
+}
+
+// WebhookSink POSTs each report as JSON to URL
+type WebhookSink struct {
+	URL string
+	// HTTPClient is used to send the request. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Notify POSTs the report to the sink's URL. Any non-2xx response is returned
+// as an error.
+func (s *WebhookSink) Notify(ctx context.Context, report *Report) error {
+	buf := new(bytes.Buffer)
+	if err := report.WriteJSON(buf); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// Detector periodically compares the live state of an organization with a
+// baseline and notifies its sinks when drift is found
+type Detector struct {
+	// Client is used to export the live state. It must be authenticated as an
+	// admin.
+	Client *bastionzero.Client
+	// Baseline is the expected state
+	Baseline *snapshot.Snapshot
+	// Interval is the time between checks. Defaults to 15 minutes.
+	Interval time.Duration
+	// Options configures each comparison
+	Options *CompareOptions
+	// Sinks are notified of every report that has drift
+	Sinks []Sink
+	// OnError is called with errors from checks and sinks. Errors are dropped
+	// if nil.
+	OnError func(error)
+}
+
+const defaultInterval = 15 * time.Minute
+
+// Check compares the live state with the baseline once
+func (d *Detector) Check(ctx context.Context) (*Report, error) {
+	live, err := snapshot.Export(ctx, d.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export live state: %w", err)
+	}
+	return Compare(d.Baseline, live, d.Options)
+}
+
+// Run checks for drift immediately and then once per interval until ctx is
+// cancelled. Reports with drift are sent to every sink. Run returns ctx.Err()
+// when ctx is cancelled.
+func (d *Detector) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Detector) runOnce(ctx context.Context) {
+	report, err := d.Check(ctx)
+	if err != nil {
+		d.reportError(err)
+		return
+	}
+	if !report.HasDrift() {
+		return
+	}
+	for _, sink := range d.Sinks {
+		if err := sink.Notify(ctx, report); err != nil {
+			d.reportError(fmt.Errorf("failed to notify sink: %w", err))
+		}
+	}
+}
+
+func (d *Detector) reportError(err error) {
+	if d.OnError != nil {
+		d.OnError(err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package drift detects configuration drift between a baseline and the live
+// state of a BastionZero organization.
+//
+// The baseline is a snapshot.Snapshot, either exported with snapshot.Export or
+// loaded from a declarative policy file with LoadPolicyFile. Compare produces a
+// structured diff per resource listing added and removed resources and
+// field-level changes (e.g. a TargetConnectPolicy's verbs or an Environment's
+// offlineCleanupTimeoutHours). A Detector runs the comparison periodically and
+// sends reports to notification sinks.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/snapshot"
+)
+
+// ChangeType describes how a resource differs from the baseline
+type ChangeType string
+
+const (
+	// Added means the resource exists in the live state but not in the
+	// baseline
+	Added ChangeType = "Added"
+	// Removed means the resource exists in the baseline but not in the live
+	// state
+	Removed ChangeType = "Removed"
+	// Modified means the resource exists in both but some of its fields differ
+	Modified ChangeType = "Modified"
+)
+
+// FieldChange is a difference in a single field of a resource. Path uses the
+// resource's JSON field names separated by dots (e.g. "verbs" or
+// "databaseAuthenticationConfig.authenticationType").
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ResourceDiff describes how one resource drifted from the baseline
+type ResourceDiff struct {
+	Kind   snapshot.Kind `json:"kind"`
+	Name   string        `json:"name"`
+	ID     string        `json:"id"`
+	Change ChangeType    `json:"change"`
+	// Fields lists the changed fields. Only set when Change is Modified.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Report is the result of comparing a baseline with the live state
+type Report struct {
+	CheckedAt time.Time      `json:"checkedAt"`
+	Diffs     []ResourceDiff `json:"diffs"`
+}
+
+// HasDrift returns true if the live state differs from the baseline
+func (r *Report) HasDrift() bool {
+	return len(r.Diffs) > 0
+}
+
+// WriteJSON writes the report to w as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// PolicyKinds are the kinds of resources that are part of a declarative
+// policy file. Pass them as CompareOptions.Kinds when the baseline was loaded
+// with LoadPolicyFile.
+var PolicyKinds = []snapshot.Kind{
+	snapshot.TargetConnectPolicyKind,
+	snapshot.KubernetesPolicyKind,
+	snapshot.ProxyPolicyKind,
+	snapshot.JITPolicyKind,
+	snapshot.OrganizationControlsPolicyKind,
+	snapshot.SessionRecordingPolicyKind,
+}
+
+// CompareOptions configures a comparison
+type CompareOptions struct {
+	// Kinds limits the comparison to these kinds of resources. Defaults to all
+	// kinds if empty.
+	Kinds []snapshot.Kind
+	// IgnoreFields lists additional field paths to ignore per kind, on top of
+	// the runtime fields that are always ignored (e.g. a target's status or
+	// agent version)
+	IgnoreFields map[snapshot.Kind][]string
+}
+
+// LoadPolicyFile reads a declarative policy file and returns it as a
+// baseline snapshot containing only policies. The file is a JSON document with
+// the same shape as snapshot.Policies:
+//
+//	{
+//	  "targetConnect": [...],
+//	  "kubernetes": [...],
+//	  "proxy": [...],
+//	  "jit": [...],
+//	  "organizationControls": [...],
+//	  "sessionRecording": [...]
+//	}
+//
+// Policies are matched with live policies by name, so IDs may be omitted.
+func LoadPolicyFile(r io.Reader) (*snapshot.Snapshot, error) {
+	s := &snapshot.Snapshot{Version: snapshot.FormatVersion}
+	if err := json.NewDecoder(r).Decode(&s.Policies); err != nil {
+		return nil, fmt.Errorf("failed to decode policy file: %w", err)
+	}
+	return s, nil
+}
+
+// Compare returns the differences between baseline and live. Resources are
+// matched by kind and name (targets are additionally matched by environment).
+func Compare(baseline, live *snapshot.Snapshot, opts *CompareOptions) (*Report, error) {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+
+	kinds := make(map[snapshot.Kind]bool)
+	for _, k := range opts.Kinds {
+		kinds[k] = true
+	}
+	included := func(k snapshot.Kind) bool { return len(kinds) == 0 || kinds[k] }
+
+	before, err := collectResources(baseline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	after, err := collectResources(live)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read live state: %w", err)
+	}
+
+	report := &Report{CheckedAt: time.Now().UTC(), Diffs: []ResourceDiff{}}
+	for key, a := range after {
+		if !included(a.kind) {
+			continue
+		}
+		b, ok := before[key]
+		if !ok {
+			report.Diffs = append(report.Diffs, ResourceDiff{Kind: a.kind, Name: a.name, ID: a.id, Change: Added})
+			continue
+		}
+
+		ignored := ignoredFields(a.kind, opts.IgnoreFields[a.kind])
+		var fields []FieldChange
+		diffValues("", b.value, a.value, ignored, &fields)
+		if len(fields) > 0 {
+			sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+			report.Diffs = append(report.Diffs, ResourceDiff{Kind: a.kind, Name: a.name, ID: a.id, Change: Modified, Fields: fields})
+		}
+	}
+	for key, b := range before {
+		if !included(b.kind) {
+			continue
+		}
+		if _, ok := after[key]; !ok {
+			report.Diffs = append(report.Diffs, ResourceDiff{Kind: b.kind, Name: b.name, ID: b.id, Change: Removed})
+		}
+	}
+
+	sort.Slice(report.Diffs, func(i, j int) bool {
+		a, b := report.Diffs[i], report.Diffs[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	return report, nil
+}
@@ -0,0 +1,241 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/snapshot"
+)
+
+// resource is a single object of a snapshot decoded into generic JSON values
+// so that it can be compared field by field
+type resource struct {
+	kind  snapshot.Kind
+	name  string
+	id    string
+	value interface{}
+}
+
+// runtimeFields are fields that change without any configuration change (or
+// are derived from other resources) and are therefore never reported as drift
+var runtimeFields = map[snapshot.Kind][]string{
+	snapshot.EnvironmentKind:                {"organizationId", "targets", "timeCreated", "isDefault"},
+	snapshot.BzeroTargetKind:                {"status", "lastAgentUpdate", "agentVersion", "region", "agentPublicKey", "controlChannel", "allowedTargetUsers", "allowedVerbs"},
+	snapshot.ClusterTargetKind:              {"status", "lastAgentUpdate", "agentVersion", "region", "agentPublicKey", "controlChannel", "allowedClusterUsers", "allowedClusterGroups", "validClusterUsers"},
+	snapshot.DatabaseTargetKind:             {"status", "lastAgentUpdate", "agentVersion", "region", "agentPublicKey", "allowedTargetUsers"},
+	snapshot.WebTargetKind:                  {"status", "lastAgentUpdate", "agentVersion", "region", "agentPublicKey"},
+	snapshot.DynamicAccessConfigurationKind: {"status", "allowedTargetUsers", "allowedVerbs"},
+	snapshot.ServiceAccountKind:             {"organizationId", "timeCreated", "lastLogin", "createdBy"},
+	snapshot.ApiKeyKind:                     {"timeCreated"},
+	snapshot.AuthorizedGitHubActionKind:     {"organizationId", "timeCreated", "createdBy"},
+}
+
+func ignoredFields(kind snapshot.Kind, extra []string) map[string]struct{} {
+	ignored := map[string]struct{}{"id": {}}
+	for _, f := range runtimeFields[kind] {
+		ignored[f] = struct{}{}
+	}
+	for _, f := range extra {
+		ignored[f] = struct{}{}
+	}
+	return ignored
+}
+
+func collectResources(s *snapshot.Snapshot) (map[string]resource, error) {
+	result := make(map[string]resource)
+	var err error
+	add := func(kind snapshot.Kind, name, id string, scope string, v interface{}) {
+		if err != nil {
+			return
+		}
+		var generic interface{}
+		generic, err = toGeneric(v)
+		if err != nil {
+			err = fmt.Errorf("%s %q: %w", kind, name, err)
+			return
+		}
+		key := fmt.Sprintf("%s/%s/%s", kind, scope, name)
+		result[key] = resource{kind: kind, name: name, id: id, value: generic}
+	}
+
+	// Targets are scoped by environment name so that the same target name can
+	// exist in several environments
+	envNames := make(map[string]string, len(s.Environments))
+	for _, e := range s.Environments {
+		envNames[e.ID] = e.Name
+	}
+	envScope := func(envID string) string {
+		if name, ok := envNames[envID]; ok {
+			return name
+		}
+		return envID
+	}
+
+	for i := range s.Environments {
+		e := &s.Environments[i]
+		add(snapshot.EnvironmentKind, e.Name, e.ID, "", e)
+	}
+	for i := range s.BzeroTargets {
+		t := &s.BzeroTargets[i]
+		add(snapshot.BzeroTargetKind, t.Name, t.ID, envScope(t.EnvironmentID), t)
+	}
+	for i := range s.ClusterTargets {
+		t := &s.ClusterTargets[i]
+		add(snapshot.ClusterTargetKind, t.Name, t.ID, envScope(t.EnvironmentID), t)
+	}
+	for i := range s.DatabaseTargets {
+		t := &s.DatabaseTargets[i]
+		add(snapshot.DatabaseTargetKind, t.Name, t.ID, envScope(t.EnvironmentID), t)
+	}
+	for i := range s.WebTargets {
+		t := &s.WebTargets[i]
+		add(snapshot.WebTargetKind, t.Name, t.ID, envScope(t.EnvironmentID), t)
+	}
+	for i := range s.DynamicAccessConfigurations {
+		d := &s.DynamicAccessConfigurations[i]
+		add(snapshot.DynamicAccessConfigurationKind, d.Name, d.ID, envScope(d.EnvironmentId), d)
+	}
+	for i := range s.Policies.TargetConnect {
+		p := &s.Policies.TargetConnect[i]
+		add(snapshot.TargetConnectPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.Policies.Kubernetes {
+		p := &s.Policies.Kubernetes[i]
+		add(snapshot.KubernetesPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.Policies.Proxy {
+		p := &s.Policies.Proxy[i]
+		add(snapshot.ProxyPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.Policies.JIT {
+		p := &s.Policies.JIT[i]
+		add(snapshot.JITPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.Policies.OrganizationControls {
+		p := &s.Policies.OrganizationControls[i]
+		add(snapshot.OrganizationControlsPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.Policies.SessionRecording {
+		p := &s.Policies.SessionRecording[i]
+		add(snapshot.SessionRecordingPolicyKind, p.Name, p.ID, "", p)
+	}
+	for i := range s.ServiceAccounts {
+		sa := &s.ServiceAccounts[i]
+		add(snapshot.ServiceAccountKind, sa.Email, sa.ID, "", sa)
+	}
+	for i := range s.ApiKeys {
+		k := &s.ApiKeys[i]
+		add(snapshot.ApiKeyKind, k.Name, k.ID, "", k)
+	}
+	for i := range s.AuthorizedGitHubActions {
+		a := &s.AuthorizedGitHubActions[i]
+		add(snapshot.AuthorizedGitHubActionKind, a.GitHubActionId, a.ID, "", a)
+	}
+	if s.RegistrationKeySettings != nil {
+		add(snapshot.RegistrationKeySettingsKind, "registration key settings", "", "", s.RegistrationKeySettings)
+	}
+
+	return result, err
+}
+
+// toGeneric converts v into the generic values produced by decoding JSON
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// diffValues appends the differences between before and after to changes.
+// Objects are compared field by field; lists are compared as unordered
+// collections and reported as a single change.
+func diffValues(path string, before, after interface{}, ignored map[string]struct{}, changes *[]FieldChange) {
+	if _, ok := ignored[path]; ok && path != "" {
+		return
+	}
+
+	beforeObj, beforeIsObj := before.(map[string]interface{})
+	afterObj, afterIsObj := after.(map[string]interface{})
+	if beforeIsObj && afterIsObj {
+		keys := make(map[string]struct{})
+		for k := range beforeObj {
+			keys[k] = struct{}{}
+		}
+		for k := range afterObj {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			diffValues(childPath, beforeObj[k], afterObj[k], ignored, changes)
+		}
+		return
+	}
+
+	if isEmpty(before) && isEmpty(after) {
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		if !reflect.DeepEqual(canonicalList(beforeList), canonicalList(afterList)) {
+			*changes = append(*changes, FieldChange{Path: path, Before: before, After: after})
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, FieldChange{Path: path, Before: before, After: after})
+	}
+}
+
+// isEmpty returns true for values that the API treats as unset
+func isEmpty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(t) == 0
+	case string:
+		return t == ""
+	default:
+		return false
+	}
+}
+
+// canonicalList returns the JSON encoding of each element of l, sorted, so
+// that lists can be compared regardless of order
+func canonicalList(l []interface{}) []string {
+	result := make([]string, len(l))
+	for i, e := range l {
+		data, _ := json.Marshal(e)
+		result[i] = string(data)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// String returns a short human readable description of the diff
+func (d ResourceDiff) String() string {
+	switch d.Change {
+	case Modified:
+		paths := make([]string, len(d.Fields))
+		for i, f := range d.Fields {
+			paths[i] = f.Path
+		}
+		return fmt.Sprintf("%s %q modified: %s", d.Kind, d.Name, strings.Join(paths, ", "))
+	default:
+		return fmt.Sprintf("%s %q %s", d.Kind, d.Name, strings.ToLower(string(d.Change)))
+	}
+}
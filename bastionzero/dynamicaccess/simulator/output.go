@@ -0,0 +1,55 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteJSON writes the report to w as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report to w as a human readable table of checks,
+// followed by the state transitions
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tCHECK\tDURATION\tMESSAGE")
+	for _, c := range r.Checks {
+		result := "PASS"
+		if !c.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result, c.Name, c.Duration.Round(time.Millisecond), c.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, t := range r.Transitions {
+		from := t.From
+		if from == "" {
+			from = "-"
+		}
+		fmt.Fprintf(w, "%s -> %s\n", from, t.To)
+	}
+
+	failed := 0
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed++
+		}
+	}
+	verdict := fmt.Sprintf("all %d checks passed", len(r.Checks))
+	if failed > 0 {
+		verdict = fmt.Sprintf("%d of %d checks FAILED", failed, len(r.Checks))
+	}
+	_, err := fmt.Fprintf(w, "\nfinal state %s, %s\n", r.FinalState, verdict)
+	return err
+}
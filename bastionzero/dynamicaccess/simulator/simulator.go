@@ -0,0 +1,350 @@
+// Package simulator tests a dynamic access configuration (DAC) provisioning
+// server locally by playing the caller's side of the webhook protocol as
+// modelled by package webhook.
+//
+// Run calls the server's health, start, health and stop webhooks in the
+// order a dynamic access target's lifecycle uses them, authenticating each
+// request with Config.Signer. It checks the status codes, response shapes and
+// timings of each call, and tracks the datstate.DATState transitions a
+// dynamic access target would go through. The result is a Report listing
+// every check.
+//
+// This SDK has no specification of the requests BastionZero sends, so a
+// report without failures shows that the server handles this SDK's model of
+// the protocol, not that it will accept requests from BastionZero.
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/dynamicaccess/webhook"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/connections/datstate"
+)
+
+const (
+	defaultStartTimeout  = 5 * time.Minute
+	defaultStopTimeout   = 5 * time.Minute
+	defaultHealthTimeout = 10 * time.Second
+)
+
+// Config describes the provisioning server under test
+type Config struct {
+	StartWebhook  string
+	StopWebhook   string
+	HealthWebhook string
+	// Signer authenticates the requests. It must match the Verifier of the
+	// server under test. If nil, requests are sent unauthenticated and no
+	// rejection checks are made.
+	Signer *Signer
+
+	// HTTPClient sends the webhook requests. Defaults to a client without a
+	// timeout; the per-webhook timeouts below apply instead.
+	HTTPClient *http.Client
+	// StartTimeout, StopTimeout and HealthTimeout bound each call. Default to
+	// 5 minutes, 5 minutes and 10 seconds.
+	StartTimeout  time.Duration
+	StopTimeout   time.Duration
+	HealthTimeout time.Duration
+
+	// StartRequest is sent to the start webhook. Defaults to a request for a
+	// target named "simulated-target" with a placeholder activation token.
+	StartRequest *webhook.StartRequest
+}
+
+// Signer authenticates the requests of a simulation
+type Signer struct {
+	// Sign authenticates a request the server should accept
+	Sign func(req *http.Request, body []byte)
+	// Rejected lists ways of authenticating a request that the server must
+	// reject with 401 or 403. Each is checked against the start and stop
+	// webhooks.
+	Rejected []RejectedSigning
+}
+
+// RejectedSigning authenticates a request in a way the server must reject
+type RejectedSigning struct {
+	// Name describes the request in check names, e.g. "invalid signature"
+	Name string
+	Sign func(req *http.Request, body []byte)
+}
+
+// HMACSigner returns a Signer for servers using webhook.HMACVerifier with
+// sharedSecret. It checks that requests signed with another secret or with a
+// timestamp an hour old are rejected.
+func HMACSigner(sharedSecret string) *Signer {
+	return &Signer{
+		Sign: func(req *http.Request, body []byte) {
+			webhook.SignRequest(req, sharedSecret, body)
+		},
+		Rejected: []RejectedSigning{
+			{
+				Name: "invalid signature",
+				Sign: func(req *http.Request, body []byte) {
+					webhook.SignRequest(req, sharedSecret+"-invalid", body)
+				},
+			},
+			{
+				Name: "stale timestamp",
+				Sign: func(req *http.Request, body []byte) {
+					timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+					req.Header.Set(webhook.HMACTimestampHeader, timestamp)
+					req.Header.Set(webhook.HMACSignatureHeader, webhook.Sign(sharedSecret, timestamp, body))
+				},
+			},
+		},
+	}
+}
+
+// Check is the outcome of a single check
+type Check struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Transition is a change in the simulated dynamic access target's state
+type Transition struct {
+	From datstate.DATState `json:"from,omitempty"`
+	To   datstate.DATState `json:"to"`
+	At   time.Time         `json:"at"`
+}
+
+// Report is the result of a simulation
+type Report struct {
+	StartedAt   time.Time    `json:"startedAt"`
+	Checks      []Check      `json:"checks"`
+	Transitions []Transition `json:"transitions"`
+	// UniqueID is the ID returned by the start webhook
+	UniqueID string `json:"uniqueId,omitempty"`
+	// FinalState is the state of the simulated target at the end
+	FinalState datstate.DATState `json:"finalState,omitempty"`
+}
+
+// Passed returns true if every check passed
+func (r *Report) Passed() bool {
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// simulation holds the state of a single Run
+type simulation struct {
+	cfg    *Config
+	client *http.Client
+	report *Report
+	state  datstate.DATState
+}
+
+// Run simulates the lifecycle of a dynamic access target against the
+// configured webhooks. Failing checks are recorded in the report; the
+// returned error is only set if cfg is incomplete or ctx is done.
+//
+// Run also sends the requests of cfg.Signer.Rejected to the start and stop
+// webhooks. A server that does not verify requests may act on them.
+func Run(ctx context.Context, cfg *Config) (*Report, error) {
+	if cfg == nil || cfg.StartWebhook == "" || cfg.StopWebhook == "" || cfg.HealthWebhook == "" {
+		return nil, fmt.Errorf("StartWebhook, StopWebhook and HealthWebhook are required")
+	}
+
+	s := &simulation{
+		cfg:    cfg,
+		client: cfg.HTTPClient,
+		report: &Report{StartedAt: time.Now().UTC()},
+	}
+	if s.client == nil {
+		s.client = &http.Client{}
+	}
+
+	s.checkHealth(ctx, "health before start")
+	s.checkRejected(ctx, "start", cfg.StartWebhook)
+
+	uniqueID := s.start(ctx)
+	if ctx.Err() != nil {
+		return s.report, ctx.Err()
+	}
+	if uniqueID != "" {
+		s.checkHealth(ctx, "health while started")
+		s.checkRejected(ctx, "stop", cfg.StopWebhook)
+		s.stop(ctx, uniqueID)
+	}
+
+	s.report.FinalState = s.state
+	return s.report, ctx.Err()
+}
+
+func (s *simulation) transition(to datstate.DATState) {
+	s.report.Transitions = append(s.report.Transitions, Transition{From: s.state, To: to, At: time.Now().UTC()})
+	s.state = to
+}
+
+func (s *simulation) record(name string, duration time.Duration, err error) {
+	check := Check{Name: name, Passed: err == nil, Duration: duration}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	s.report.Checks = append(s.report.Checks, check)
+}
+
+func (s *simulation) start(ctx context.Context) string {
+	request := s.cfg.StartRequest
+	if request == nil {
+		request = &webhook.StartRequest{
+			ActivationToken: "simulated-activation-token",
+			TargetName:      "simulated-target",
+		}
+	}
+
+	s.transition(datstate.Starting)
+	response := new(webhook.StartResponse)
+	duration, err := s.call(ctx, s.cfg.StartWebhook, http.MethodPost, request, timeout(s.cfg.StartTimeout, defaultStartTimeout), response)
+	if err == nil && response.UniqueID == "" {
+		err = fmt.Errorf("response has no uniqueId")
+	}
+	s.record("start", duration, err)
+	if err != nil {
+		s.transition(datstate.StartError)
+		return ""
+	}
+
+	s.transition(datstate.Started)
+	s.report.UniqueID = response.UniqueID
+	return response.UniqueID
+}
+
+func (s *simulation) stop(ctx context.Context, uniqueID string) {
+	s.transition(datstate.Stopping)
+	duration, err := s.call(ctx, s.cfg.StopWebhook, http.MethodPost, &webhook.StopRequest{UniqueID: uniqueID}, timeout(s.cfg.StopTimeout, defaultStopTimeout), nil)
+	s.record("stop", duration, err)
+	if err != nil {
+		s.transition(datstate.StopError)
+		return
+	}
+	s.transition(datstate.Stopped)
+}
+
+func (s *simulation) checkHealth(ctx context.Context, name string) {
+	response := new(webhook.HealthResponse)
+	duration, err := s.call(ctx, s.cfg.HealthWebhook, http.MethodPost, &webhook.HealthRequest{}, timeout(s.cfg.HealthTimeout, defaultHealthTimeout), response)
+	if err == nil && !response.Healthy {
+		err = fmt.Errorf("response reports unhealthy")
+	}
+	s.record(name, duration, err)
+}
+
+// checkRejected sends the requests of cfg.Signer.Rejected to url and expects
+// a 401 or 403 response to each
+func (s *simulation) checkRejected(ctx context.Context, webhookName string, url string) {
+	if s.cfg.Signer == nil {
+		return
+	}
+	for _, rejected := range s.cfg.Signer.Rejected {
+		s.checkRejectedRequest(ctx, fmt.Sprintf("%s rejects %s", webhookName, rejected.Name), url, rejected.Sign)
+	}
+}
+
+// checkRejectedRequest sends a request authenticated by sign and expects a
+// 401 or 403 response
+func (s *simulation) checkRejectedRequest(ctx context.Context, name string, url string, sign func(req *http.Request, body []byte)) {
+	body := []byte("{}")
+	ctx, cancel := context.WithTimeout(ctx, timeout(s.cfg.HealthTimeout, defaultHealthTimeout))
+	defer cancel()
+
+	began := time.Now()
+	err := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		sign(req, body)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+			return fmt.Errorf("expected status 401 or 403, got %d", resp.StatusCode)
+		}
+		return nil
+	}()
+	s.record(name, time.Since(began), err)
+}
+
+// call sends a signed request and decodes a successful response into v. It
+// returns an error if the call fails, times out, or the response does not
+// have the expected shape.
+func (s *simulation) call(ctx context.Context, url string, method string, request interface{}, limit time.Duration, v interface{}) (time.Duration, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Signer != nil && s.cfg.Signer.Sign != nil {
+		s.cfg.Signer.Sign(req, body)
+	}
+
+	began := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return time.Since(began), fmt.Errorf("no response within %s", limit)
+		}
+		return time.Since(began), err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	duration := time.Since(began)
+	if err != nil {
+		return duration, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return duration, fmt.Errorf("expected Content-Type application/json, got %q", resp.Header.Get("Content-Type"))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errorResponse := new(webhook.ErrorResponse)
+		if err := json.Unmarshal(respBody, errorResponse); err != nil || errorResponse.ErrorMessage == "" {
+			return duration, fmt.Errorf("status %d without an errorMessage in the response", resp.StatusCode)
+		}
+		return duration, fmt.Errorf("status %d: %s", resp.StatusCode, errorResponse.ErrorMessage)
+	}
+
+	if v != nil {
+		if err := json.Unmarshal(respBody, v); err != nil {
+			return duration, fmt.Errorf("invalid response body: %w", err)
+		}
+	}
+	return duration, nil
+}
+
+func timeout(d time.Duration, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
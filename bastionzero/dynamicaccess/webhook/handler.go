@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultMaxBodyBytes = 1 << 20
+
+// HandlerOptions specifies the parameters to NewHandler
+type HandlerOptions struct {
+	// Verifier authenticates every request before it is decoded. It is
+	// required; see the package documentation.
+	Verifier Verifier
+	// StartPath, StopPath and HealthPath are the paths of the webhooks.
+	// Default to "/start", "/stop" and "/health".
+	StartPath  string
+	StopPath   string
+	HealthPath string
+	// MaxBodyBytes limits the size of request bodies. Defaults to 1 MiB.
+	MaxBodyBytes int64
+	// OnError is called with errors returned by the Provisioner and with
+	// requests that fail verification or decoding. Errors are dropped if nil.
+	OnError func(r *http.Request, err error)
+}
+
+// handler serves the start, stop and health webhooks
+type handler struct {
+	provisioner Provisioner
+	opts        HandlerOptions
+	mux         *http.ServeMux
+}
+
+// NewHandler returns an http.Handler that serves the webhooks of a DAC and
+// dispatches them to p. Requests must use POST; the health webhook also
+// accepts GET with an empty body. It returns an error if opts.Verifier is not
+// set.
+func NewHandler(p Provisioner, opts *HandlerOptions) (http.Handler, error) {
+	if opts == nil || opts.Verifier == nil {
+		return nil, errors.New("HandlerOptions.Verifier is required")
+	}
+	h := &handler{provisioner: p, opts: *opts}
+	if h.opts.StartPath == "" {
+		h.opts.StartPath = "/start"
+	}
+	if h.opts.StopPath == "" {
+		h.opts.StopPath = "/stop"
+	}
+	if h.opts.HealthPath == "" {
+		h.opts.HealthPath = "/health"
+	}
+	if h.opts.MaxBodyBytes <= 0 {
+		h.opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc(h.opts.StartPath, h.start)
+	h.mux.HandleFunc(h.opts.StopPath, h.stop)
+	h.mux.HandleFunc(h.opts.HealthPath, h.health)
+	return h, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) start(w http.ResponseWriter, r *http.Request) {
+	request := new(StartRequest)
+	if !h.decode(w, r, request, false) {
+		return
+	}
+	if request.ActivationToken == "" {
+		h.fail(w, r, &Error{StatusCode: http.StatusBadRequest, Message: "activationToken is required"})
+		return
+	}
+
+	response, err := h.provisioner.Start(r.Context(), request)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if response == nil || response.UniqueID == "" {
+		h.fail(w, r, errors.New("provisioner returned no unique ID"))
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *handler) stop(w http.ResponseWriter, r *http.Request) {
+	request := new(StopRequest)
+	if !h.decode(w, r, request, false) {
+		return
+	}
+	if request.UniqueID == "" {
+		h.fail(w, r, &Error{StatusCode: http.StatusBadRequest, Message: "uniqueId is required"})
+		return
+	}
+
+	if err := h.provisioner.Stop(r.Context(), request); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *handler) health(w http.ResponseWriter, r *http.Request) {
+	request := new(HealthRequest)
+	if !h.decode(w, r, request, true) {
+		return
+	}
+
+	if err := h.provisioner.Health(r.Context(), request); err != nil {
+		if h.opts.OnError != nil {
+			h.opts.OnError(r, err)
+		}
+		writeJSON(w, http.StatusServiceUnavailable, &HealthResponse{Healthy: false})
+		return
+	}
+	writeJSON(w, http.StatusOK, &HealthResponse{Healthy: true})
+}
+
+// decode verifies the request and decodes its body into v. It writes an error response and returns false on failure.
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, v interface{}, allowGet bool) bool {
+	if r.Method != http.MethodPost && !(allowGet && r.Method == http.MethodGet) {
+		h.fail(w, r, &Error{StatusCode: http.StatusMethodNotAllowed, Message: fmt.Sprintf("method %s is not allowed", r.Method)})
+		return false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes))
+	if err != nil {
+		h.fail(w, r, &Error{StatusCode: http.StatusRequestEntityTooLarge, Message: "failed to read request body"})
+		return false
+	}
+	if err := h.opts.Verifier(r, body); err != nil {
+		var webhookErr *Error
+		if !errors.As(err, &webhookErr) {
+			err = &Error{StatusCode: http.StatusUnauthorized, Message: err.Error()}
+		}
+		h.fail(w, r, err)
+		return false
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if r.Method == http.MethodGet {
+			return true
+		}
+		h.fail(w, r, &Error{StatusCode: http.StatusBadRequest, Message: "request body is required"})
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		h.fail(w, r, &Error{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid request body: %s", err)})
+		return false
+	}
+	return true
+}
+
+// fail writes an ErrorResponse. err's status code is used if it is an
+// *Error, and 500 otherwise.
+func (h *handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(r, err)
+	}
+
+	status := http.StatusInternalServerError
+	message := err.Error()
+	var webhookErr *Error
+	if errors.As(err, &webhookErr) {
+		status = webhookErr.StatusCode
+		message = webhookErr.Message
+	}
+	writeJSON(w, status, &ErrorResponse{ErrorMessage: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the HMAC scheme implemented by HMACVerifier and SignRequest.
+// They are defined by this package, not by BastionZero.
+const (
+	// HMACSignatureHeader carries "sha256=" followed by the hex encoded
+	// HMAC-SHA256, keyed with the shared secret, of
+	// "<timestamp>.<raw request body>"
+	HMACSignatureHeader = "X-Webhook-Signature"
+	// HMACTimestampHeader carries the Unix time, in seconds, the request was
+	// signed at
+	HMACTimestampHeader = "X-Webhook-Timestamp"
+)
+
+const (
+	signaturePrefix     = "sha256="
+	defaultMaxClockSkew = 5 * time.Minute
+)
+
+// HMACVerifier returns a Verifier for requests signed with SignRequest. It
+// rejects requests whose signature does not match or whose timestamp is more
+// than maxClockSkew from the current time, so that captured requests cannot
+// be replayed later. maxClockSkew defaults to 5 minutes.
+func HMACVerifier(sharedSecret string, maxClockSkew time.Duration) Verifier {
+	if maxClockSkew <= 0 {
+		maxClockSkew = defaultMaxClockSkew
+	}
+	return func(r *http.Request, body []byte) error {
+		timestamp := r.Header.Get(HMACTimestampHeader)
+		if !Verify(sharedSecret, timestamp, body, r.Header.Get(HMACSignatureHeader)) {
+			return errors.New("invalid signature")
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errors.New("invalid timestamp")
+		}
+		skew := time.Since(time.Unix(seconds, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxClockSkew {
+			return errors.New("timestamp is outside the allowed clock skew")
+		}
+		return nil
+	}
+}
+
+// Sign returns the value of HMACSignatureHeader for body sent with the given
+// value of HMACTimestampHeader
+func Sign(sharedSecret string, timestamp string, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac(sharedSecret, timestamp, body))
+}
+
+// Verify returns true if signature is the signature of body sent with the
+// given value of HMACTimestampHeader. It does not check the timestamp itself.
+func Verify(sharedSecret string, timestamp string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(got, mac(sharedSecret, timestamp, body))
+}
+
+// SignRequest sets the HMACTimestampHeader and HMACSignatureHeader headers of
+// r, whose body is body, to sign it at the current time
+func SignRequest(r *http.Request, sharedSecret string, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	r.Header.Set(HMACTimestampHeader, timestamp)
+	r.Header.Set(HMACSignatureHeader, Sign(sharedSecret, timestamp, body))
+}
+
+func mac(sharedSecret string, timestamp string, body []byte) []byte {
+	m := hmac.New(sha256.New, []byte(sharedSecret))
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return m.Sum(nil)
+}
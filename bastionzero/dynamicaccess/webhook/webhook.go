@@ -0,0 +1,105 @@
+// Package webhook implements the provisioning server side of dynamic access
+// configurations (DACs).
+//
+// A DAC points BastionZero at three webhooks on a provisioning server: start,
+// stop and health. NewHandler returns an http.Handler serving all three. It
+// verifies each request with HandlerOptions.Verifier, decodes the payload,
+// and dispatches it to a Provisioner.
+//
+// This SDK does not know how BastionZero authenticates webhook requests with
+// the DAC's shared secret, so there is no default Verifier: supply one that
+// implements the scheme your requests actually use. The payload types below
+// are likewise modelled on the fields of the SDK's DAC and connection types
+// rather than on documented request bodies; check them against real traffic.
+//
+// HMACVerifier and SignRequest implement a simple HMAC scheme defined by this
+// package. BastionZero is not known to use it; it is meant for requests whose
+// sender you control, e.g. a signing proxy or tests with net/http/httptest.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Verifier authenticates a webhook request, whose body has already been read
+// into body. A non-nil error rejects the request with 401 Unauthorized, or
+// with the status of an *Error.
+type Verifier func(r *http.Request, body []byte) error
+
+// StartRequest is sent to the start webhook when a user connects to a
+// dynamic access target. The provisioning server should create a target and
+// register a Bzero agent on it with ActivationToken.
+type StartRequest struct {
+	// ActivationToken registers the agent of the new target with BastionZero
+	ActivationToken string `json:"activationToken"`
+	// TargetName is the name the new target must be registered with
+	TargetName string `json:"targetName"`
+	// EnvironmentID is the ID of the environment of the DAC
+	EnvironmentID string `json:"environmentId"`
+	// DynamicAccessConfigurationID is the ID of the DAC
+	DynamicAccessConfigurationID string `json:"dynamicAccessConfigurationId"`
+	// ConnectionID is the ID of the connection that triggered the start
+	ConnectionID string `json:"connectionId"`
+}
+
+// StartResponse is returned by the start webhook
+type StartResponse struct {
+	// UniqueID identifies the provisioned target. It is passed back to the
+	// stop webhook and reported as the connection's
+	// ProvisioningServerUniqueId.
+	UniqueID string `json:"uniqueId"`
+}
+
+// StopRequest is sent to the stop webhook when a dynamic access target is no
+// longer needed. The provisioning server should tear the target down.
+type StopRequest struct {
+	// UniqueID is the value returned by the start webhook
+	UniqueID string `json:"uniqueId"`
+	// DynamicAccessConfigurationID is the ID of the DAC
+	DynamicAccessConfigurationID string `json:"dynamicAccessConfigurationId"`
+	// ConnectionID is the ID of the connection the target was started for
+	ConnectionID string `json:"connectionId"`
+}
+
+// HealthRequest is sent to the health webhook to determine the DAC's status
+type HealthRequest struct {
+	// DynamicAccessConfigurationID is the ID of the DAC
+	DynamicAccessConfigurationID string `json:"dynamicAccessConfigurationId"`
+}
+
+// HealthResponse is returned by the health webhook
+type HealthResponse struct {
+	Healthy bool `json:"healthy"`
+}
+
+// ErrorResponse is returned by any webhook that fails. BastionZero reports
+// ErrorMessage as the connection's ProvisioningServerErrorMessage.
+type ErrorResponse struct {
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// Provisioner creates and destroys dynamic access targets. It is implemented
+// by the user of this package.
+type Provisioner interface {
+	// Start provisions a target. A non-nil error fails the start; return an
+	// *Error to control the status code.
+	Start(ctx context.Context, request *StartRequest) (*StartResponse, error)
+	// Stop tears down a target previously provisioned by Start
+	Stop(ctx context.Context, request *StopRequest) error
+	// Health returns nil if the provisioner can serve start and stop
+	// requests. The DAC is reported Offline otherwise.
+	Health(ctx context.Context, request *HealthRequest) error
+}
+
+// Error is an error with an HTTP status code. Provisioner methods can return
+// it to respond with a status other than 500.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,214 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/agentconfig"
+)
+
+const defaultVerifyTimeout = 2 * time.Minute
+
+// Selector selects Bzero targets. Each non-empty field restricts the
+// selection further.
+type Selector struct {
+	// EnvironmentIDs selects targets in one of these environments
+	EnvironmentIDs []string
+	// NamePattern selects targets whose name matches this pattern (see
+	// path.Match)
+	NamePattern string
+	// AgentVersionPattern selects targets whose agent version matches this
+	// pattern (see path.Match), e.g. "7.*"
+	AgentVersionPattern string
+}
+
+// Matches returns true if t is selected. Malformed patterns match nothing.
+func (s *Selector) Matches(t *targets.BzeroTarget) bool {
+	if len(s.EnvironmentIDs) > 0 {
+		found := false
+		for _, id := range s.EnvironmentIDs {
+			if id == t.EnvironmentID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if s.NamePattern != "" {
+		if ok, _ := path.Match(s.NamePattern, t.Name); !ok {
+			return false
+		}
+	}
+	if s.AgentVersionPattern != "" {
+		if ok, _ := path.Match(s.AgentVersionPattern, t.AgentVersion); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the targets in list selected by s
+func (s *Selector) Select(list []targets.BzeroTarget) []targets.BzeroTarget {
+	var result []targets.BzeroTarget
+	for _, t := range list {
+		if s.Matches(&t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// ConfigStatus is the outcome of setting one key on one target
+type ConfigStatus string
+
+const (
+	// ConfigApplied means the update was accepted but the agent does not
+	// expose the key, so the change could not be verified
+	ConfigApplied ConfigStatus = "Applied"
+	// ConfigVerified means the update was accepted and the target reflects
+	// the new value
+	ConfigVerified ConfigStatus = "Verified"
+	// ConfigFailed means the update was rejected or could not be verified in
+	// time
+	ConfigFailed ConfigStatus = "Failed"
+)
+
+// ApplyConfigOptions specifies the optional parameters to ApplyAgentConfig
+type ApplyConfigOptions struct {
+	// Catalogue is used to validate the config. Defaults to
+	// agentconfig.DefaultCatalogue.
+	Catalogue agentconfig.Catalogue
+	// Selector limits the targets the config is applied to. Defaults to all
+	// given targets.
+	Selector *Selector
+	// MaxConcurrency is the maximum number of targets updated at the same
+	// time. Defaults to 5.
+	MaxConcurrency int
+	// VerifyTimeout is how long to wait for a target to reflect a verifiable
+	// key. Defaults to 2 minutes.
+	VerifyTimeout time.Duration
+	// PollInterval is the time between verification checks. Defaults to 5
+	// seconds.
+	PollInterval time.Duration
+}
+
+// ConfigResult is the outcome of setting one key on one target
+type ConfigResult struct {
+	TargetID   string       `json:"targetId"`
+	TargetName string       `json:"targetName"`
+	Key        string       `json:"key"`
+	Value      string       `json:"value"`
+	Status     ConfigStatus `json:"status"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// ApplyAgentConfig sets every key in config on each selected target. The
+// config is validated against the catalogue first; nothing is sent if it is
+// invalid. Keys are applied to a target one at a time in name order.
+//
+// The returned results have one entry per selected target and key, ordered
+// by target and then key.
+func ApplyAgentConfig(ctx context.Context, client *bastionzero.Client, list []targets.BzeroTarget, config map[string]string, opts *ApplyConfigOptions) ([]ConfigResult, error) {
+	if opts == nil {
+		opts = &ApplyConfigOptions{}
+	}
+	catalogue := opts.Catalogue
+	if catalogue == nil {
+		catalogue = agentconfig.DefaultCatalogue
+	}
+	if err := catalogue.Validate(config); err != nil {
+		return nil, err
+	}
+	if opts.Selector != nil {
+		list = opts.Selector.Select(list)
+	}
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrency
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]ConfigResult, 0, len(list)*len(names))
+	for _, t := range list {
+		for _, name := range names {
+			results = append(results, ConfigResult{TargetID: t.ID, TargetName: t.Name, Key: name, Value: config[name]})
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range list {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			for j := range names {
+				result := &results[i*len(names)+j]
+				status, err := applyKey(ctx, client, &list[i], catalogue[result.Key], result.Value, opts)
+				result.Status = status
+				if err != nil {
+					result.Error = err.Error()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func applyKey(ctx context.Context, client *bastionzero.Client, target *targets.BzeroTarget, key agentconfig.Key, value string, opts *ApplyConfigOptions) (ConfigStatus, error) {
+	request, err := key.Request(target.ID, value)
+	if err != nil {
+		return ConfigFailed, err
+	}
+	if _, err := client.Targets.UpdateAgentConfig(ctx, request); err != nil {
+		return ConfigFailed, fmt.Errorf("failed to update agent config: %w", err)
+	}
+	if key.Verify == nil {
+		return ConfigApplied, nil
+	}
+
+	timeout := opts.VerifyTimeout
+	if timeout <= 0 {
+		timeout = defaultVerifyTimeout
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		current, _, err := client.Targets.GetBzeroTarget(ctx, target.ID)
+		if err == nil && key.Verify(current, value) {
+			return ConfigVerified, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ConfigFailed, fmt.Errorf("target did not reflect %s=%s: %w", key.Name, value, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
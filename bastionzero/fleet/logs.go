@@ -0,0 +1,241 @@
+package fleet
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/internal"
+)
+
+const (
+	defaultLogsTimeout      = 10 * time.Minute
+	defaultLogsPollInterval = 10 * time.Second
+)
+
+// ErrLogsNotReady is returned by a LogSource when the logs of a target have
+// not been uploaded yet
+var ErrLogsNotReady = errors.New("logs not ready")
+
+// LogSource retrieves the logs an agent uploaded in response to
+// RequestBzeroTargetLogs.
+//
+// The BastionZero API does not expose an endpoint to check the progress of an
+// upload or to download its result, so the caller provides the LogSource (e.g.
+// one reading from wherever uploaded logs are delivered in their
+// organization).
+type LogSource interface {
+	// Fetch returns the logs uploaded by the target for the given upload
+	// request. It returns ErrLogsNotReady (possibly wrapped) if the upload has
+	// not completed yet.
+	Fetch(ctx context.Context, targetID string, uploadLogsRequestID string) (io.ReadCloser, error)
+}
+
+// LogSourceFunc adapts an ordinary function to the LogSource interface
+type LogSourceFunc func(ctx context.Context, targetID string, uploadLogsRequestID string) (io.ReadCloser, error)
+
+// Fetch calls f(ctx, targetID, uploadLogsRequestID)
+func (f LogSourceFunc) Fetch(ctx context.Context, targetID string, uploadLogsRequestID string) (io.ReadCloser, error) {
+	return f(ctx, targetID, uploadLogsRequestID)
+}
+
+// LogsStatus is the outcome of retrieving a single target's logs
+type LogsStatus string
+
+const (
+	// LogsRequested means the upload was requested but not waited for
+	// because no LogSource was given
+	LogsRequested LogsStatus = "Requested"
+	// LogsDownloaded means the logs were written to Path
+	LogsDownloaded LogsStatus = "Downloaded"
+	// LogsTimedOut means the logs were not available before the timeout
+	LogsTimedOut LogsStatus = "TimedOut"
+	// LogsFailed means requesting, fetching or writing the logs failed
+	LogsFailed LogsStatus = "Failed"
+)
+
+// CollectLogsOptions specifies the optional parameters to
+// CollectBzeroTargetLogs
+type CollectLogsOptions struct {
+	// Source is where uploaded logs are fetched from. If nil, the uploads are
+	// only requested.
+	Source LogSource
+	// MaxConcurrency is the maximum number of targets processed at the same
+	// time. Defaults to 5.
+	MaxConcurrency int
+	// Timeout is how long to wait for each target's logs after the upload was
+	// requested. Defaults to 10 minutes.
+	Timeout time.Duration
+	// PollInterval is the time between fetch attempts. Defaults to 10
+	// seconds.
+	PollInterval time.Duration
+}
+
+// LogsResult is the outcome of retrieving a single target's logs
+type LogsResult struct {
+	TargetID   string `json:"targetId"`
+	TargetName string `json:"targetName"`
+	// UploadLogsRequestID is the ID sent with the upload request
+	UploadLogsRequestID string     `json:"uploadLogsRequestId"`
+	Status              LogsStatus `json:"status"`
+	// Path is the file the logs were written to. Empty unless Status is
+	// LogsDownloaded.
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// NewUploadLogsRequestID returns a random ID (a version 4 UUID) to use as a
+// RequestBzeroAgentLogsRequest's UploadLogsRequestId
+func NewUploadLogsRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate upload logs request ID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// CollectBzeroTargetLogs requests that each target's agent uploads its logs,
+// waits for the uploads to complete, and writes them to dir as
+// "<name>-<id>.log". dir is created if it does not exist.
+//
+// The returned results have one entry per target, in the order given.
+// Per-target failures are reported in the results; the returned error is only
+// set if dir cannot be created.
+func CollectBzeroTargetLogs(ctx context.Context, client *bastionzero.Client, list []targets.BzeroTarget, dir string, opts *CollectLogsOptions) ([]LogsResult, error) {
+	if opts == nil {
+		opts = &CollectLogsOptions{}
+	}
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrency
+	}
+	if opts.Source != nil {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	results := make([]LogsResult, len(list))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range list {
+		results[i] = LogsResult{TargetID: list[i].ID, TargetName: list[i].Name}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *LogsResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := collectLogs(ctx, client, result, dir, opts); err != nil {
+				if result.Status == "" {
+					result.Status = LogsFailed
+				}
+				result.Error = err.Error()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func collectLogs(ctx context.Context, client *bastionzero.Client, result *LogsResult, dir string, opts *CollectLogsOptions) error {
+	requestID, err := NewUploadLogsRequestID()
+	if err != nil {
+		return err
+	}
+	result.UploadLogsRequestID = requestID
+
+	if _, err := client.Targets.RequestBzeroTargetLogs(ctx, &targets.RequestBzeroAgentLogsRequest{
+		TargetID:            result.TargetID,
+		UploadLogsRequestId: requestID,
+	}); err != nil {
+		return fmt.Errorf("failed to request logs: %w", err)
+	}
+	if opts.Source == nil {
+		result.Status = LogsRequested
+		return nil
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultLogsTimeout
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultLogsPollInterval
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		logs, err := opts.Source.Fetch(waitCtx, result.TargetID, requestID)
+		if err == nil {
+			path := filepath.Join(dir, logFileName(result.TargetName, result.TargetID))
+			if err := writeFile(path, logs); err != nil {
+				return err
+			}
+			result.Status = LogsDownloaded
+			result.Path = path
+			return nil
+		}
+		if !errors.Is(err, ErrLogsNotReady) {
+			return fmt.Errorf("failed to fetch logs: %w", err)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() == nil {
+				result.Status = LogsTimedOut
+				return fmt.Errorf("logs were not uploaded within %s", timeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeFile copies r to path through a temporary file so that a partially
+// written file is never left at path
+func writeFile(path string, r io.ReadCloser) error {
+	defer r.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write logs: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write logs: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write logs: %w", err)
+	}
+	return nil
+}
+
+// logFileName returns the name of the file a target's logs are written to.
+// Characters that are not safe in file names are replaced.
+func logFileName(name string, id string) string {
+	return fmt.Sprintf("%s-%s.log", internal.SanitizeName(name), id)
+}
@@ -0,0 +1,267 @@
+// Package fleet runs operations across many Bzero agents at once.
+//
+// RestartBzeroTargets restarts agents in waves with bounded concurrency. After
+// each restart it waits for the agent to come back Online on a new control
+// channel, and it halts the rollout when too many restarts fail.
+//
+// CollectBzeroTargetLogs requests log uploads from many agents, waits for
+// them through a caller provided LogSource, and writes the logs to a
+// directory.
+//
+// ApplyAgentConfig validates a config against the agentconfig catalogue and
+// applies it to the targets chosen by a Selector.
+//
+// FetchVersionReport groups agents by version and flags those below a minimum
+// version or lagging behind the newest agent in the fleet.
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+)
+
+const (
+	defaultWaveSize       = 10
+	defaultMaxConcurrency = 5
+	defaultOnlineTimeout  = 5 * time.Minute
+	defaultPollInterval   = 5 * time.Second
+
+	// clockSkew is subtracted from the local time of a restart request before
+	// comparing it with timestamps reported by BastionZero
+	clockSkew = 30 * time.Second
+)
+
+// RestartStatus is the outcome of restarting a single target
+type RestartStatus string
+
+const (
+	// Restarted means the agent restarted and came back Online on a new
+	// control channel
+	Restarted RestartStatus = "Restarted"
+	// RestartFailed means the restart request failed or the agent did not
+	// come back Online in time
+	RestartFailed RestartStatus = "Failed"
+	// RestartSkipped means the target was not restarted because the rollout
+	// halted or ctx was cancelled first
+	RestartSkipped RestartStatus = "Skipped"
+)
+
+// RestartOptions specifies the optional parameters to RestartBzeroTargets
+type RestartOptions struct {
+	// WaveSize is the number of targets restarted per wave. The failure rate
+	// is checked between waves. Defaults to 10.
+	WaveSize int
+	// MaxConcurrency is the maximum number of targets restarted at the same
+	// time within a wave. Defaults to 5.
+	MaxConcurrency int
+	// OnlineTimeout is how long to wait for a target to come back Online
+	// after its restart was requested. Defaults to 5 minutes.
+	OnlineTimeout time.Duration
+	// PollInterval is the time between health checks of a restarting target.
+	// Defaults to 5 seconds.
+	PollInterval time.Duration
+	// MaxFailureRate is the fraction (0 to 1) of processed targets allowed to
+	// fail. The rollout halts after the first wave that pushes the failure
+	// rate above it. Zero halts after any failure.
+	MaxFailureRate float64
+	// OnResult is called with the result of each target as soon as it is
+	// known. It may be called concurrently.
+	OnResult func(RestartResult)
+}
+
+// RestartResult is the outcome of restarting a single target
+type RestartResult struct {
+	TargetID   string        `json:"targetId"`
+	TargetName string        `json:"targetName"`
+	Status     RestartStatus `json:"status"`
+	// Wave is the zero-based index of the wave the target belonged to
+	Wave int `json:"wave"`
+	// Duration is the time from the restart request until the target was
+	// back Online or the restart failed
+	Duration time.Duration `json:"duration"`
+	// Error describes why the restart failed or was skipped
+	Error string `json:"error,omitempty"`
+}
+
+// RestartReport describes the outcome of a rollout
+type RestartReport struct {
+	Results []RestartResult `json:"results"`
+	// Halted is true if the rollout stopped early because the failure rate
+	// exceeded MaxFailureRate
+	Halted bool `json:"halted"`
+}
+
+// Count returns the number of results with the given status
+func (r *RestartReport) Count(status RestartStatus) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// RestartBzeroTargets restarts the given targets in waves. Within a wave at
+// most MaxConcurrency targets are restarting at once. A target counts as
+// restarted once it is Online with a control channel and last agent update
+// newer than its restart request.
+//
+// The returned report has one result per target, in the order given. If ctx
+// is cancelled, the remaining targets are skipped and ctx.Err() is returned
+// along with the report.
+func RestartBzeroTargets(ctx context.Context, client *bastionzero.Client, list []targets.BzeroTarget, opts *RestartOptions) (*RestartReport, error) {
+	if opts == nil {
+		opts = &RestartOptions{}
+	}
+	waveSize := opts.WaveSize
+	if waveSize <= 0 {
+		waveSize = defaultWaveSize
+	}
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrency
+	}
+
+	report := &RestartReport{Results: make([]RestartResult, len(list))}
+	for i := range list {
+		report.Results[i] = RestartResult{
+			TargetID:   list[i].ID,
+			TargetName: list[i].Name,
+			Status:     RestartSkipped,
+			Wave:       i / waveSize,
+		}
+	}
+
+	processed, failed := 0, 0
+	for start := 0; start < len(list); start += waveSize {
+		if ctx.Err() != nil {
+			markSkipped(report.Results[start:], ctx.Err().Error())
+			return report, ctx.Err()
+		}
+
+		end := start + waveSize
+		if end > len(list) {
+			end = len(list)
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				result := &report.Results[i]
+				began := time.Now()
+				if err := restartAndWait(ctx, client, &list[i], opts); err != nil {
+					result.Status = RestartFailed
+					result.Error = err.Error()
+				} else {
+					result.Status = Restarted
+				}
+				result.Duration = time.Since(began)
+				if opts.OnResult != nil {
+					opts.OnResult(*result)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			markSkipped(report.Results[end:], ctx.Err().Error())
+			return report, ctx.Err()
+		}
+
+		for _, result := range report.Results[start:end] {
+			processed++
+			if result.Status == RestartFailed {
+				failed++
+			}
+		}
+		if float64(failed)/float64(processed) > opts.MaxFailureRate {
+			report.Halted = end < len(list)
+			markSkipped(report.Results[end:], fmt.Sprintf("rollout halted: %d of %d restarts failed", failed, processed))
+			return report, nil
+		}
+	}
+
+	return report, nil
+}
+
+func markSkipped(results []RestartResult, reason string) {
+	for i := range results {
+		results[i].Status = RestartSkipped
+		results[i].Error = reason
+	}
+}
+
+// restartAndWait restarts the target and waits until it is healthy again
+func restartAndWait(ctx context.Context, client *bastionzero.Client, target *targets.BzeroTarget, opts *RestartOptions) error {
+	timeout := opts.OnlineTimeout
+	if timeout <= 0 {
+		timeout = defaultOnlineTimeout
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	var previousChannel string
+	if target.ControlChannel != nil {
+		previousChannel = target.ControlChannel.ControlChannelID
+	}
+
+	requestedAt := time.Now().Add(-clockSkew)
+	if _, err := client.Targets.RestartBzeroTarget(ctx, &targets.RestartBzeroTargetRequest{TargetID: target.ID}); err != nil {
+		return fmt.Errorf("failed to request restart: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastStatus := target.Status
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("agent did not come back Online (last status %s): %w", lastStatus, ctx.Err())
+		case <-ticker.C:
+		}
+
+		current, _, err := client.Targets.GetBzeroTarget(ctx, target.ID)
+		if err != nil {
+			// Keep polling; the deadline bounds how long errors are tolerated
+			continue
+		}
+		lastStatus = current.Status
+		if restarted(current, previousChannel, requestedAt) {
+			return nil
+		}
+	}
+}
+
+// restarted returns true if target is Online with an active control channel
+// and agent update that are newer than the restart request
+func restarted(target *targets.BzeroTarget, previousChannel string, requestedAt time.Time) bool {
+	if target.Status != targetstatus.Online {
+		return false
+	}
+	channel := target.ControlChannel
+	if channel == nil || channel.EndTime != nil || channel.ControlChannelID == previousChannel {
+		return false
+	}
+	if channel.StartTime.Before(requestedAt) {
+		return false
+	}
+	return target.LastAgentUpdate != nil && !target.LastAgentUpdate.Before(requestedAt)
+}
@@ -0,0 +1,280 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/agents"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/agents/agentstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/agents/agenttype"
+)
+
+// Version is a parsed semantic version. Pre-release and build suffixes are
+// ignored.
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// ParseVersion parses versions such as "7.3.1", "v7.3" and "7.3.1-beta". A
+// missing minor or patch number is treated as 0.
+func ParseVersion(s string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 || parts[0] == "" {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	var numbers [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		numbers[i] = n
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if v is older than, equal to or newer than o
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] < d[1] {
+			return -1
+		}
+		if d[0] > d[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Flag describes why an agent is out of compliance
+type Flag string
+
+const (
+	// BelowMinimum means the agent is older than the minimum version
+	BelowMinimum Flag = "BelowMinimum"
+	// Lagging means the agent is more than MaxMinorLag minor versions (or a
+	// major version) behind the newest agent in the fleet
+	Lagging Flag = "Lagging"
+	// UnknownVersion means the agent's version could not be parsed
+	UnknownVersion Flag = "UnknownVersion"
+)
+
+// VersionReportOptions specifies the optional parameters to
+// BuildVersionReport
+type VersionReportOptions struct {
+	// MinimumVersion flags agents older than this version if set
+	MinimumVersion string
+	// MaxMinorLag flags agents more than this many minor versions behind the
+	// newest agent in the fleet. Agents on an older major version are always
+	// lagging. If zero, lag is not checked.
+	MaxMinorLag int
+}
+
+// AgentVersion describes the version of a single agent
+type AgentVersion struct {
+	ID              string                  `json:"id"`
+	Name            string                  `json:"name"`
+	Type            agenttype.AgentType     `json:"type"`
+	Status          agentstatus.AgentStatus `json:"status"`
+	EnvironmentID   string                  `json:"environmentId"`
+	EnvironmentName string                  `json:"environmentName"`
+	Region          string                  `json:"region"`
+	Version         string                  `json:"version"`
+	// MinorsBehind is the number of minor versions the agent is behind the
+	// newest agent with the same major version. -1 if the agent is on an
+	// older major version or its version is unknown.
+	MinorsBehind int    `json:"minorsBehind"`
+	Flags        []Flag `json:"flags,omitempty"`
+}
+
+// VersionGroup counts the agents sharing a version, type, environment and
+// region
+type VersionGroup struct {
+	Version         string              `json:"version"`
+	Type            agenttype.AgentType `json:"type"`
+	EnvironmentName string              `json:"environmentName"`
+	Region          string              `json:"region"`
+	Count           int                 `json:"count"`
+	// Flagged is the number of agents in the group with at least one flag
+	Flagged int `json:"flagged"`
+}
+
+// VersionReport describes the agent versions across an organization
+type VersionReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// NewestVersion is the newest version found in the fleet. Empty if no
+	// version could be parsed.
+	NewestVersion  string         `json:"newestVersion"`
+	MinimumVersion string         `json:"minimumVersion,omitempty"`
+	MaxMinorLag    int            `json:"maxMinorLag,omitempty"`
+	Agents         []AgentVersion `json:"agents"`
+	Groups         []VersionGroup `json:"groups"`
+}
+
+// Flagged returns the agents with at least one flag
+func (r *VersionReport) Flagged() []AgentVersion {
+	var result []AgentVersion
+	for _, a := range r.Agents {
+		if len(a.Flags) > 0 {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// FetchVersionReport lists the organization's agents (excluding terminated
+// agents) and builds a VersionReport from them
+func FetchVersionReport(ctx context.Context, client *bastionzero.Client, opts *VersionReportOptions) (*VersionReport, error) {
+	list, _, err := client.Agents.ListAgents(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	return BuildVersionReport(list, opts)
+}
+
+// BuildVersionReport builds a VersionReport from a list of agents. Agents are
+// sorted by name and groups by version (newest first), type, environment and
+// region.
+func BuildVersionReport(list []agents.AgentDetails, opts *VersionReportOptions) (*VersionReport, error) {
+	if opts == nil {
+		opts = &VersionReportOptions{}
+	}
+	var minimum *Version
+	if opts.MinimumVersion != "" {
+		v, err := ParseVersion(opts.MinimumVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum version: %w", err)
+		}
+		minimum = &v
+	}
+
+	parsed := make([]*Version, len(list))
+	var newest *Version
+	for i, a := range list {
+		v, err := ParseVersion(a.Version)
+		if err != nil {
+			continue
+		}
+		parsed[i] = &v
+		if newest == nil || v.Compare(*newest) > 0 {
+			newest = &v
+		}
+	}
+
+	report := &VersionReport{
+		GeneratedAt:    time.Now().UTC(),
+		MinimumVersion: opts.MinimumVersion,
+		MaxMinorLag:    opts.MaxMinorLag,
+		Agents:         make([]AgentVersion, len(list)),
+	}
+	if newest != nil {
+		report.NewestVersion = newest.String()
+	}
+
+	for i, a := range list {
+		entry := AgentVersion{
+			ID:              a.Id,
+			Name:            a.Name,
+			Type:            a.AgentType,
+			Status:          a.AgentStatus,
+			EnvironmentID:   a.EnvironmentId,
+			EnvironmentName: a.EnvironmentName,
+			Region:          a.Region,
+			Version:         a.Version,
+			MinorsBehind:    -1,
+		}
+
+		v := parsed[i]
+		if v == nil {
+			entry.Flags = append(entry.Flags, UnknownVersion)
+		} else {
+			if minimum != nil && v.Compare(*minimum) < 0 {
+				entry.Flags = append(entry.Flags, BelowMinimum)
+			}
+			if v.Major == newest.Major {
+				entry.MinorsBehind = newest.Minor - v.Minor
+			}
+			if opts.MaxMinorLag > 0 && (entry.MinorsBehind < 0 || entry.MinorsBehind > opts.MaxMinorLag) {
+				entry.Flags = append(entry.Flags, Lagging)
+			}
+		}
+		report.Agents[i] = entry
+	}
+
+	sort.SliceStable(report.Agents, func(i, j int) bool {
+		return report.Agents[i].Name < report.Agents[j].Name
+	})
+	report.Groups = groupVersions(report.Agents)
+	return report, nil
+}
+
+func groupVersions(list []AgentVersion) []VersionGroup {
+	type key struct {
+		version     string
+		agentType   agenttype.AgentType
+		environment string
+		region      string
+	}
+	groups := make(map[key]*VersionGroup)
+	var order []key
+	for _, a := range list {
+		k := key{a.Version, a.Type, a.EnvironmentName, a.Region}
+		g, ok := groups[k]
+		if !ok {
+			g = &VersionGroup{Version: a.Version, Type: a.Type, EnvironmentName: a.EnvironmentName, Region: a.Region}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.Count++
+		if len(a.Flags) > 0 {
+			g.Flagged++
+		}
+	}
+
+	result := make([]VersionGroup, len(order))
+	for i, k := range order {
+		result[i] = *groups[k]
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Version != b.Version {
+			va, errA := ParseVersion(a.Version)
+			vb, errB := ParseVersion(b.Version)
+			switch {
+			case errA != nil && errB != nil:
+				return a.Version < b.Version
+			case errA != nil || errB != nil:
+				// Unparseable versions go last
+				return errB != nil
+			case va.Compare(vb) != 0:
+				return va.Compare(vb) > 0
+			default:
+				return a.Version < b.Version
+			}
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.EnvironmentName != b.EnvironmentName {
+			return a.EnvironmentName < b.EnvironmentName
+		}
+		return a.Region < b.Region
+	})
+	return result
+}
@@ -0,0 +1,127 @@
+package fleet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the report to w as indented JSON
+func (r *VersionReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report to w as human readable tables: one row per
+// group, followed by the flagged agents
+func (r *VersionReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tTYPE\tENVIRONMENT\tREGION\tAGENTS\tFLAGGED")
+	for _, g := range r.Groups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\n", g.Version, g.Type, g.EnvironmentName, g.Region, g.Count, g.Flagged)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	flagged := r.Flagged()
+	if len(flagged) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "AGENT\tVERSION\tTYPE\tENVIRONMENT\tFLAGS")
+		for _, a := range flagged {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", a.Name, a.Version, a.Type, a.EnvironmentName, joinFlags(a.Flags))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d agent(s), %d flagged, newest version %s\n", len(r.Agents), len(flagged), r.NewestVersion)
+	return err
+}
+
+var versionCSVHeader = []string{
+	"id", "name", "type", "status", "environment_id", "environment_name", "region",
+	"version", "minors_behind", "flags",
+}
+
+// WriteCSV writes the report to w as CSV with one row per agent. Flags are
+// joined with semicolons.
+func (r *VersionReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(versionCSVHeader); err != nil {
+		return err
+	}
+	for _, a := range r.Agents {
+		row := []string{
+			a.ID, a.Name, string(a.Type), string(a.Status), a.EnvironmentID, a.EnvironmentName, a.Region,
+			a.Version, strconv.Itoa(a.MinorsBehind), joinFlags(a.Flags),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePrometheus writes the report to w in the Prometheus text exposition
+// format, e.g. to be served by a metrics endpoint or picked up by the node
+// exporter's textfile collector
+func (r *VersionReport) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("# HELP bastionzero_agents Number of agents by version, type, environment and region.\n")
+	b.WriteString("# TYPE bastionzero_agents gauge\n")
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "bastionzero_agents{%s} %d\n", groupLabels(g), g.Count)
+	}
+
+	b.WriteString("# HELP bastionzero_agents_flagged Number of agents by version, type, environment and region that are out of compliance.\n")
+	b.WriteString("# TYPE bastionzero_agents_flagged gauge\n")
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "bastionzero_agents_flagged{%s} %d\n", groupLabels(g), g.Flagged)
+	}
+
+	b.WriteString("# HELP bastionzero_agent_minors_behind Minor versions an agent is behind the newest agent, or -1 if it is on an older major version or its version is unknown.\n")
+	b.WriteString("# TYPE bastionzero_agent_minors_behind gauge\n")
+	for _, a := range r.Agents {
+		fmt.Fprintf(&b, "bastionzero_agent_minors_behind{id=%s,name=%s,version=%s} %d\n",
+			quoteLabel(a.ID), quoteLabel(a.Name), quoteLabel(a.Version), a.MinorsBehind)
+	}
+
+	if r.NewestVersion != "" {
+		b.WriteString("# HELP bastionzero_agent_newest_version_info Newest agent version in the fleet.\n")
+		b.WriteString("# TYPE bastionzero_agent_newest_version_info gauge\n")
+		fmt.Fprintf(&b, "bastionzero_agent_newest_version_info{version=%s} 1\n", quoteLabel(r.NewestVersion))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func groupLabels(g VersionGroup) string {
+	return fmt.Sprintf("version=%s,type=%s,environment=%s,region=%s",
+		quoteLabel(g.Version), quoteLabel(string(g.Type)), quoteLabel(g.EnvironmentName), quoteLabel(g.Region))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabel quotes a Prometheus label value
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func joinFlags(flags []Flag) string {
+	s := make([]string, len(flags))
+	for i, f := range flags {
+		s[i] = string(f)
+	}
+	return strings.Join(s, ";")
+}
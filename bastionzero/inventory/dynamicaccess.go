@@ -0,0 +1,43 @@
+package inventory
+
+import (
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dacstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// DynamicAccessTarget adapts a dynamic access configuration to
+// targets.TargetInterface. DACs have no agent of their own, so the agent
+// related methods return zero values.
+type DynamicAccessTarget struct {
+	*targets.DynamicAccessConfiguration
+}
+
+var _ targets.TargetInterface = &DynamicAccessTarget{}
+
+func (t *DynamicAccessTarget) GetID() string            { return t.ID }
+func (t *DynamicAccessTarget) GetName() string          { return t.Name }
+func (t *DynamicAccessTarget) GetEnvironmentID() string { return t.EnvironmentId }
+
+// GetStatus maps the DAC's health status to a target status. Any status other
+// than Online or Offline is returned as Error.
+func (t *DynamicAccessTarget) GetStatus() targetstatus.TargetStatus {
+	switch t.Status {
+	case dacstatus.DACOnline:
+		return targetstatus.Online
+	case dacstatus.DACOffline:
+		return targetstatus.Offline
+	default:
+		return targetstatus.Error
+	}
+}
+
+func (t *DynamicAccessTarget) GetLastAgentUpdate() *types.Timestamp { return nil }
+func (t *DynamicAccessTarget) GetAgentVersion() string              { return "" }
+func (t *DynamicAccessTarget) GetRegion() string                    { return "" }
+func (t *DynamicAccessTarget) GetAgentPublicKey() string            { return "" }
+func (t *DynamicAccessTarget) GetTargetType() targettype.TargetType {
+	return targettype.DynamicAccessConfig
+}
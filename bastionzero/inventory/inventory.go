@@ -0,0 +1,315 @@
+// Package inventory builds a unified view of every target in an organization.
+//
+// TargetsService has a separate List method per kind of target, and dynamic
+// access configurations (DACs) do not implement targets.TargetInterface.
+// Fetch lists every kind concurrently and returns an Inventory of
+// []targets.TargetInterface, with DACs adapted by DynamicAccessTarget. The
+// inventory is indexed by ID, name and environment and can be filtered by
+// status, agent version, region and type.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// FetchOptions specifies the optional parameters to Fetch
+type FetchOptions struct {
+	// Types limits the inventory to these types of targets. Defaults to all
+	// types if empty.
+	Types []targettype.TargetType
+	// IncludeAccessDetails attaches the AccessDetails reported by
+	// AllTargetsService.ListAllTargets to each target. This describes how the
+	// caller can access each target (e.g. through JIT) and is most useful when
+	// the client is authenticated as a non-admin.
+	IncludeAccessDetails bool
+}
+
+// Inventory is a snapshot of the targets in an organization
+type Inventory struct {
+	targets       []targets.TargetInterface
+	byID          map[string]targets.TargetInterface
+	byName        map[string][]targets.TargetInterface
+	byEnvironment map[string][]targets.TargetInterface
+	accessDetails map[string]*targets_disambiguated.AccessDetails
+}
+
+// Fetch lists the targets of every kind concurrently and returns them as an
+// Inventory. The remaining requests are cancelled as soon as one fails.
+func Fetch(ctx context.Context, client *bastionzero.Client, opts *FetchOptions) (*Inventory, error) {
+	if opts == nil {
+		opts = &FetchOptions{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	included := func(t targettype.TargetType) bool {
+		if len(opts.Types) == 0 {
+			return true
+		}
+		for _, e := range opts.Types {
+			if e == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		all           []targets.TargetInterface
+		accessDetails map[string]*targets_disambiguated.AccessDetails
+		firstErr      error
+	)
+	run := func(f func() ([]targets.TargetInterface, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := f()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			all = append(all, result...)
+		}()
+	}
+
+	if included(targettype.Bzero) {
+		run(func() ([]targets.TargetInterface, error) {
+			list, _, err := client.Targets.ListBzeroTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Bzero targets: %w", err)
+			}
+			result := make([]targets.TargetInterface, len(list))
+			for i := range list {
+				result[i] = &list[i]
+			}
+			return result, nil
+		})
+	}
+	if included(targettype.Cluster) {
+		run(func() ([]targets.TargetInterface, error) {
+			list, _, err := client.Targets.ListClusterTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+			}
+			result := make([]targets.TargetInterface, len(list))
+			for i := range list {
+				result[i] = &list[i]
+			}
+			return result, nil
+		})
+	}
+	if included(targettype.Db) {
+		run(func() ([]targets.TargetInterface, error) {
+			list, _, err := client.Targets.ListDatabaseTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Database targets: %w", err)
+			}
+			result := make([]targets.TargetInterface, len(list))
+			for i := range list {
+				result[i] = &list[i]
+			}
+			return result, nil
+		})
+	}
+	if included(targettype.Web) {
+		run(func() ([]targets.TargetInterface, error) {
+			list, _, err := client.Targets.ListWebTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Web targets: %w", err)
+			}
+			result := make([]targets.TargetInterface, len(list))
+			for i := range list {
+				result[i] = &list[i]
+			}
+			return result, nil
+		})
+	}
+	if included(targettype.DynamicAccessConfig) {
+		run(func() ([]targets.TargetInterface, error) {
+			list, _, err := client.Targets.ListDynamicAccessConfigurations(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list dynamic access configurations: %w", err)
+			}
+			result := make([]targets.TargetInterface, len(list))
+			for i := range list {
+				result[i] = &DynamicAccessTarget{DynamicAccessConfiguration: &list[i]}
+			}
+			return result, nil
+		})
+	}
+	if opts.IncludeAccessDetails {
+		run(func() ([]targets.TargetInterface, error) {
+			allTargets, _, err := client.AllTargets.ListAllTargets(ctx, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list access details: %w", err)
+			}
+			details := AccessDetailsByID(allTargets)
+			mu.Lock()
+			accessDetails = details
+			mu.Unlock()
+			return nil, nil
+		})
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return New(all, accessDetails), nil
+}
+
+// New builds an Inventory from a list of targets. accessDetails maps target
+// IDs to their access details and may be nil.
+func New(list []targets.TargetInterface, accessDetails map[string]*targets_disambiguated.AccessDetails) *Inventory {
+	sorted := append([]targets.TargetInterface{}, list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].GetName() != sorted[j].GetName() {
+			return sorted[i].GetName() < sorted[j].GetName()
+		}
+		return sorted[i].GetID() < sorted[j].GetID()
+	})
+
+	inv := &Inventory{
+		targets:       sorted,
+		byID:          make(map[string]targets.TargetInterface, len(sorted)),
+		byName:        make(map[string][]targets.TargetInterface),
+		byEnvironment: make(map[string][]targets.TargetInterface),
+		accessDetails: accessDetails,
+	}
+	if inv.accessDetails == nil {
+		inv.accessDetails = make(map[string]*targets_disambiguated.AccessDetails)
+	}
+	for _, t := range sorted {
+		inv.byID[t.GetID()] = t
+		inv.byName[t.GetName()] = append(inv.byName[t.GetName()], t)
+		inv.byEnvironment[t.GetEnvironmentID()] = append(inv.byEnvironment[t.GetEnvironmentID()], t)
+	}
+	return inv
+}
+
+// AccessDetailsByID maps the IDs of the targets in a ListAllTargets response
+// to their access details
+func AccessDetailsByID(all *targets_disambiguated.AllTargetsResponse) map[string]*targets_disambiguated.AccessDetails {
+	result := make(map[string]*targets_disambiguated.AccessDetails)
+	add := func(t *targets_disambiguated.Target) {
+		if t.AccessDetails != nil {
+			result[t.ID] = t.AccessDetails
+		}
+	}
+	for i := range all.Db {
+		add(&all.Db[i].Target)
+	}
+	for i := range all.Kubernetes {
+		add(&all.Kubernetes[i].Target)
+	}
+	for i := range all.FileTransfer {
+		add(&all.FileTransfer[i].Target)
+	}
+	for i := range all.Rdp {
+		add(&all.Rdp[i].Target)
+	}
+	for i := range all.Shell {
+		add(&all.Shell[i].Target)
+	}
+	for i := range all.Ssh {
+		add(&all.Ssh[i].Target)
+	}
+	for i := range all.SqlServer {
+		add(&all.SqlServer[i].Target)
+	}
+	for i := range all.Web {
+		add(&all.Web[i].Target)
+	}
+	return result
+}
+
+// All returns every target in the inventory, sorted by name
+func (inv *Inventory) All() []targets.TargetInterface {
+	return append([]targets.TargetInterface{}, inv.targets...)
+}
+
+// Len returns the number of targets in the inventory
+func (inv *Inventory) Len() int {
+	return len(inv.targets)
+}
+
+// ByID returns the target with the given ID, or nil if there is none
+func (inv *Inventory) ByID(id string) targets.TargetInterface {
+	return inv.byID[id]
+}
+
+// ByName returns the targets with the given name. Target names are only
+// unique within an environment, so there may be more than one.
+func (inv *Inventory) ByName(name string) []targets.TargetInterface {
+	return append([]targets.TargetInterface{}, inv.byName[name]...)
+}
+
+// ByEnvironment returns the targets in the environment with the given ID
+func (inv *Inventory) ByEnvironment(environmentID string) []targets.TargetInterface {
+	return append([]targets.TargetInterface{}, inv.byEnvironment[environmentID]...)
+}
+
+// AccessDetails returns the access details of the target with the given ID,
+// or nil if they were not fetched or the caller has no access to the target
+func (inv *Inventory) AccessDetails(id string) *targets_disambiguated.AccessDetails {
+	return inv.accessDetails[id]
+}
+
+// Filter selects targets. Each non-empty field restricts the selection to
+// targets matching one of its values.
+type Filter struct {
+	Statuses       []targetstatus.TargetStatus
+	AgentVersions  []string
+	Regions        []string
+	Types          []targettype.TargetType
+	EnvironmentIDs []string
+}
+
+// Matches returns true if t is selected by the filter
+func (f *Filter) Matches(t targets.TargetInterface) bool {
+	return matches(f.Statuses, t.GetStatus()) &&
+		matches(f.AgentVersions, t.GetAgentVersion()) &&
+		matches(f.Regions, t.GetRegion()) &&
+		matches(f.Types, t.GetTargetType()) &&
+		matches(f.EnvironmentIDs, t.GetEnvironmentID())
+}
+
+// Filter returns the targets selected by f, sorted by name
+func (inv *Inventory) Filter(f Filter) []targets.TargetInterface {
+	var result []targets.TargetInterface
+	for _, t := range inv.targets {
+		if f.Matches(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func matches[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+// Package kubeconfig generates kubeconfig files for the Kubernetes clusters
+// accessible through BastionZero.
+//
+// Generate builds a Config with one context per cluster returned by
+// AllTargetsService.ListAllTargets. Each context points at the local daemon
+// and authenticates with an exec credential plugin whose arguments select the
+// cluster, environment, cluster user and cluster groups. Merge and MergeFile
+// add the generated entries to an existing kubeconfig without touching
+// unrelated clusters, contexts and users.
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/internal"
+)
+
+const (
+	defaultNamePrefix  = "bzero-"
+	defaultServer      = "https://localhost:%d"
+	defaultBasePort    = 6443
+	defaultPortRange   = 10000
+	defaultExecCommand = "zli"
+	execAPIVersion     = "client.authentication.k8s.io/v1beta1"
+)
+
+// Config is a kubeconfig. Only the fields written by this package are
+// modelled; use Merge to preserve everything else in an existing file.
+type Config struct {
+	APIVersion     string         `yaml:"apiVersion"`
+	Kind           string         `yaml:"kind"`
+	Clusters       []NamedCluster `yaml:"clusters"`
+	Contexts       []NamedContext `yaml:"contexts"`
+	Users          []NamedUser    `yaml:"users"`
+	CurrentContext string         `yaml:"current-context,omitempty"`
+}
+
+// NamedCluster is an entry of a kubeconfig's clusters
+type NamedCluster struct {
+	Name    string  `yaml:"name"`
+	Cluster Cluster `yaml:"cluster"`
+}
+
+// Cluster describes how to reach a cluster
+type Cluster struct {
+	Server               string `yaml:"server"`
+	CertificateAuthority string `yaml:"certificate-authority,omitempty"`
+}
+
+// NamedContext is an entry of a kubeconfig's contexts
+type NamedContext struct {
+	Name    string  `yaml:"name"`
+	Context Context `yaml:"context"`
+}
+
+// Context pairs a cluster with a user
+type Context struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// NamedUser is an entry of a kubeconfig's users
+type NamedUser struct {
+	Name string   `yaml:"name"`
+	User AuthInfo `yaml:"user"`
+}
+
+// AuthInfo describes how a user authenticates
+type AuthInfo struct {
+	Exec *ExecConfig `yaml:"exec,omitempty"`
+}
+
+// ExecConfig configures an exec credential plugin
+type ExecConfig struct {
+	APIVersion      string   `yaml:"apiVersion"`
+	Command         string   `yaml:"command"`
+	Args            []string `yaml:"args"`
+	InteractiveMode string   `yaml:"interactiveMode,omitempty"`
+}
+
+// Options specifies the optional parameters to Generate
+type Options struct {
+	// NamePrefix is prepended to the names of the generated clusters,
+	// contexts and users. It also marks entries as generated for
+	// MergeOptions.Prune. Defaults to "bzero-".
+	NamePrefix string
+	// Server returns the URL of the local daemon serving the cluster.
+	// Defaults to https://localhost:<port>, where the port is derived from
+	// the entry name so that it does not change when other clusters are
+	// added or removed (see DefaultPort).
+	Server func(target *targets_disambiguated.KubeTarget) string
+	// CertificateAuthority is the path to the certificate of the local daemon
+	CertificateAuthority string
+	// ClusterUser returns the cluster user to authenticate as. Defaults to the
+	// first allowed cluster user. Clusters without an allowed user are
+	// skipped.
+	ClusterUser func(target *targets_disambiguated.KubeTarget) string
+	// ExecCommand is the credential plugin. Defaults to "zli".
+	ExecCommand string
+	// ExecArgs returns the arguments of the credential plugin. Defaults to
+	// DefaultExecArgs.
+	ExecArgs func(target *targets_disambiguated.KubeTarget, user string, groups []string) []string
+	// Namespace is set as the default namespace of every context
+	Namespace string
+}
+
+// DefaultExecArgs returns the default arguments of the credential plugin:
+// "kube exec-credential --target <name> --environment <environment>
+// --user <user>" followed by "--group <group>" for each group
+func DefaultExecArgs(target *targets_disambiguated.KubeTarget, user string, groups []string) []string {
+	args := []string{"kube", "exec-credential", "--target", target.Name, "--environment", target.EnvironmentName, "--user", user}
+	for _, g := range groups {
+		args = append(args, "--group", g)
+	}
+	return args
+}
+
+// Name returns the name of the generated entries for a cluster:
+// prefix + "<environment>-<cluster>"
+func Name(prefix string, target *targets_disambiguated.KubeTarget) string {
+	return prefix + internal.SanitizeName(target.EnvironmentName) + "-" + internal.SanitizeName(target.Name)
+}
+
+// DefaultPort returns the local port of the cluster with the given entry name
+// (see Name) when Options.Server is not set: a port between 6443 and 16442
+// derived from a hash of the name. Generate moves a cluster whose port is
+// already taken by a cluster earlier in name order to the next free port, so
+// only clusters whose names hash to the same port can affect each other.
+func DefaultPort(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return defaultBasePort + int(h.Sum32()%defaultPortRange)
+}
+
+// Fetch lists the clusters accessible to the client and generates a config
+// for them
+func Fetch(ctx context.Context, client *bastionzero.Client, opts *Options) (*Config, error) {
+	allTargets, _, err := client.AllTargets.ListAllTargets(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	return Generate(allTargets.Kubernetes, opts)
+}
+
+// Generate builds a config with one context per cluster, sorted by name. The
+// cluster's allowed groups are all passed to the credential plugin. It
+// returns an error if two clusters have the same entry name, e.g. "a b" and
+// "a_b" in the same environment.
+func Generate(list []targets_disambiguated.KubeTarget, opts *Options) (*Config, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	prefix := opts.NamePrefix
+	if prefix == "" {
+		prefix = defaultNamePrefix
+	}
+	command := opts.ExecCommand
+	if command == "" {
+		command = defaultExecCommand
+	}
+	execArgs := opts.ExecArgs
+	if execArgs == nil {
+		execArgs = DefaultExecArgs
+	}
+	usedPorts := make(map[int]bool)
+	server := opts.Server
+	if server == nil {
+		server = func(t *targets_disambiguated.KubeTarget) string {
+			port := DefaultPort(Name(prefix, t))
+			for usedPorts[port] {
+				port = defaultBasePort + (port-defaultBasePort+1)%defaultPortRange
+			}
+			usedPorts[port] = true
+			return fmt.Sprintf(defaultServer, port)
+		}
+	}
+
+	sorted := make([]*targets_disambiguated.KubeTarget, len(list))
+	for i := range list {
+		sorted[i] = &list[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return Name(prefix, sorted[i]) < Name(prefix, sorted[j])
+	})
+
+	cfg := &Config{APIVersion: "v1", Kind: "Config"}
+	seen := make(map[string]string)
+	for _, t := range sorted {
+		user := ""
+		if opts.ClusterUser != nil {
+			user = opts.ClusterUser(t)
+		} else if len(t.AllowedClusterUsers) > 0 {
+			user = t.AllowedClusterUsers[0]
+		}
+		if user == "" {
+			continue
+		}
+
+		name := Name(prefix, t)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("entry name %q is used by clusters %s and %s", name, other, t.ID)
+		}
+		seen[name] = t.ID
+		cfg.Clusters = append(cfg.Clusters, NamedCluster{
+			Name: name,
+			Cluster: Cluster{
+				Server:               server(t),
+				CertificateAuthority: opts.CertificateAuthority,
+			},
+		})
+		cfg.Users = append(cfg.Users, NamedUser{
+			Name: name,
+			User: AuthInfo{Exec: &ExecConfig{
+				APIVersion:      execAPIVersion,
+				Command:         command,
+				Args:            execArgs(t, user, t.AllowedClusterGroups),
+				InteractiveMode: "Never",
+			}},
+		})
+		cfg.Contexts = append(cfg.Contexts, NamedContext{
+			Name:    name,
+			Context: Context{Cluster: name, User: name, Namespace: opts.Namespace},
+		})
+	}
+	return cfg, nil
+}
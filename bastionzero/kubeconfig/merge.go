@@ -0,0 +1,230 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entryLists are the lists of named entries in a kubeconfig
+var entryLists = []string{"clusters", "contexts", "users"}
+
+// MergeOptions specifies the optional parameters to Merge
+type MergeOptions struct {
+	// Prune removes entries whose names start with PrunePrefix but are not
+	// part of the generated config, e.g. clusters that are no longer
+	// accessible
+	Prune       bool
+	PrunePrefix string
+	// SetCurrentContext sets current-context to this context if not empty
+	SetCurrentContext string
+}
+
+// WriteYAML writes the config to w
+func (c *Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Merge adds the entries of generated to the kubeconfig in existing and
+// returns the result. Entries with the same name are replaced in place and
+// new entries are appended; every other entry and field of existing is kept,
+// along with its comments and order. existing may be empty.
+func Merge(existing []byte, generated *Config, opts *MergeOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &MergeOptions{}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := yaml.Unmarshal(existing, doc); err != nil {
+			return nil, fmt.Errorf("failed to parse existing kubeconfig: %w", err)
+		}
+		switch {
+		case len(doc.Content) == 0 || doc.Content[0].Tag == "!!null":
+			// A file with only comments or an explicit null
+			doc.Kind = yaml.DocumentNode
+			doc.Content = []*yaml.Node{root}
+		case doc.Content[0].Kind != yaml.MappingNode:
+			return nil, fmt.Errorf("failed to parse existing kubeconfig: not a mapping")
+		default:
+			root = doc.Content[0]
+		}
+	}
+	if mappingValue(root, "kind") == nil {
+		prependMappingValue(root, "kind", scalarNode("Config"))
+	}
+	if mappingValue(root, "apiVersion") == nil {
+		prependMappingValue(root, "apiVersion", scalarNode("v1"))
+	}
+
+	overlay := new(yaml.Node)
+	if err := overlay.Encode(generated); err != nil {
+		return nil, err
+	}
+
+	for _, list := range entryLists {
+		var added []*yaml.Node
+		if v := mappingValue(overlay, list); v != nil {
+			added = v.Content
+		}
+		seq := mappingValue(root, list)
+		if seq == nil || seq.Kind != yaml.SequenceNode {
+			seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setMappingValue(root, list, seq)
+		}
+
+		pending := make(map[string]*yaml.Node, len(added))
+		for _, e := range added {
+			pending[entryName(e)] = e
+		}
+
+		merged := make([]*yaml.Node, 0, len(seq.Content)+len(added))
+		for _, e := range seq.Content {
+			name := entryName(e)
+			if replacement, ok := pending[name]; ok {
+				replacement.HeadComment, replacement.LineComment, replacement.FootComment = e.HeadComment, e.LineComment, e.FootComment
+				merged = append(merged, replacement)
+				delete(pending, name)
+				continue
+			}
+			if opts.Prune && opts.PrunePrefix != "" && strings.HasPrefix(name, opts.PrunePrefix) {
+				continue
+			}
+			merged = append(merged, e)
+		}
+		for _, e := range added {
+			if _, ok := pending[entryName(e)]; ok {
+				merged = append(merged, e)
+			}
+		}
+		seq.Content = merged
+		// Block style, in case the list was written as [] before
+		seq.Style = 0
+	}
+
+	if opts.SetCurrentContext != "" {
+		setMappingValue(root, "current-context", scalarNode(opts.SetCurrentContext))
+	} else if current := mappingValue(root, "current-context"); current != nil && current.Value != "" && !hasEntry(mappingValue(root, "contexts"), current.Value) {
+		// The current context was pruned
+		deleteMappingValue(root, "current-context")
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MergeFile merges generated into the kubeconfig at path, creating it if it
+// does not exist. The file is replaced atomically.
+func MergeFile(path string, generated *Config, opts *MergeOptions) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	merged, err := Merge(existing, generated, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(merged); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// mappingValue returns the value of key in the mapping node m, or nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value of key in the mapping node m, or appends
+// key if m does not have it
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, scalarNode(key), value)
+}
+
+// prependMappingValue adds key to the start of the mapping node m
+func prependMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	m.Content = append([]*yaml.Node{scalarNode(key), value}, m.Content...)
+}
+
+// deleteMappingValue removes key from the mapping node m
+func deleteMappingValue(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// entryName returns the name of an entry of a list of named entries
+func entryName(e *yaml.Node) string {
+	if name := mappingValue(e, "name"); name != nil {
+		return name.Value
+	}
+	return ""
+}
+
+func hasEntry(list *yaml.Node, name string) bool {
+	if list == nil {
+		return false
+	}
+	for _, e := range list.Content {
+		if entryName(e) == name {
+			return true
+		}
+	}
+	return false
+}
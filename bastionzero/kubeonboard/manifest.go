@@ -0,0 +1,279 @@
+package kubeonboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clusterScopedKinds lists the kinds that must not be given a namespace
+var clusterScopedKinds = map[string]struct{}{
+	"Namespace":                      {},
+	"ClusterRole":                    {},
+	"ClusterRoleBinding":             {},
+	"CustomResourceDefinition":       {},
+	"PersistentVolume":               {},
+	"StorageClass":                   {},
+	"PriorityClass":                  {},
+	"MutatingWebhookConfiguration":   {},
+	"ValidatingWebhookConfiguration": {},
+}
+
+// podTemplateKinds lists the kinds whose spec.template is a pod template
+var podTemplateKinds = map[string]struct{}{
+	"Deployment":  {},
+	"StatefulSet": {},
+	"DaemonSet":   {},
+	"ReplicaSet":  {},
+	"Job":         {},
+}
+
+// Object is a single Kubernetes object from a manifest
+type Object struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	// Content is the whole object, including the fields above. Changes to
+	// the fields above are written back to Content by the Manifest methods;
+	// other changes must be made to Content directly.
+	Content map[string]interface{}
+}
+
+// IsClusterScoped returns true if the object's kind is not namespaced
+func (o *Object) IsClusterScoped() bool {
+	_, ok := clusterScopedKinds[o.Kind]
+	return ok
+}
+
+// String identifies the object without revealing its content
+func (o *Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// GoString is the same as String so that the object's content is not printed
+// with %#v
+func (o *Object) GoString() string {
+	return o.String()
+}
+
+// Manifest is the parsed agent manifest returned by GenerateKubeYAML
+type Manifest struct {
+	Objects []*Object
+	// secrets are redacted from errors and String
+	secrets []string
+}
+
+// ParseManifest parses a multi-document YAML manifest. Occurrences of the
+// given secrets (e.g. the activation token) are redacted from parse errors.
+func ParseManifest(manifest string, secrets ...string) (*Manifest, error) {
+	m := &Manifest{secrets: secrets}
+	dec := yaml.NewDecoder(strings.NewReader(manifest))
+	for i := 0; ; i++ {
+		var content map[string]interface{}
+		err := dec.Decode(&content)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest document %d: %s", i, m.redact(err.Error()))
+		}
+		if content == nil {
+			// Empty document
+			continue
+		}
+
+		o := &Object{Content: content}
+		o.APIVersion, _ = content["apiVersion"].(string)
+		o.Kind, _ = content["kind"].(string)
+		if o.Kind == "" {
+			return nil, fmt.Errorf("manifest document %d has no kind", i)
+		}
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			o.Name, _ = metadata["name"].(string)
+			o.Namespace, _ = metadata["namespace"].(string)
+		}
+		m.Objects = append(m.Objects, o)
+	}
+	if len(m.Objects) == 0 {
+		return nil, fmt.Errorf("manifest contains no objects")
+	}
+	return m, nil
+}
+
+// String summarizes the manifest without revealing its content
+func (m *Manifest) String() string {
+	names := make([]string, len(m.Objects))
+	for i, o := range m.Objects {
+		names[i] = o.String()
+	}
+	return fmt.Sprintf("manifest with %d object(s): %s", len(m.Objects), strings.Join(names, ", "))
+}
+
+// GoString is the same as String so that the manifest's content is not
+// printed with %#v
+func (m *Manifest) GoString() string {
+	return m.String()
+}
+
+func (m *Manifest) redact(s string) string {
+	for _, secret := range m.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+// Resources are the compute resources of the agent's containers, e.g.
+// {"cpu": "500m", "memory": "512Mi"}
+type Resources struct {
+	Limits   map[string]string
+	Requests map[string]string
+}
+
+// Overrides are changes applied to a manifest
+type Overrides struct {
+	// Namespace moves every namespaced object (and the Namespace object, if
+	// any) to this namespace
+	Namespace string
+	// Labels are added to every object and to the pod templates
+	Labels map[string]string
+	// Resources are set on every container of every pod template
+	Resources *Resources
+}
+
+// Apply applies the overrides to the manifest
+func (m *Manifest) Apply(o *Overrides) {
+	if o == nil {
+		return
+	}
+	if o.Namespace != "" {
+		m.setNamespace(o.Namespace)
+	}
+	for _, obj := range m.Objects {
+		if len(o.Labels) > 0 {
+			addLabels(obj.Content, o.Labels)
+			if template := podTemplate(obj); template != nil {
+				addLabels(template, o.Labels)
+			}
+		}
+		if o.Resources != nil {
+			if template := podTemplate(obj); template != nil {
+				setResources(template, o.Resources)
+			}
+		}
+	}
+}
+
+func (m *Manifest) setNamespace(namespace string) {
+	var previous string
+	for _, obj := range m.Objects {
+		if obj.Kind == "Namespace" {
+			previous = obj.Name
+			obj.Name = namespace
+			child(obj.Content, "metadata")["name"] = namespace
+		}
+	}
+
+	for _, obj := range m.Objects {
+		if !obj.IsClusterScoped() {
+			obj.Namespace = namespace
+			child(obj.Content, "metadata")["namespace"] = namespace
+		}
+
+		// Role bindings refer to service accounts by namespace
+		if obj.Kind == "RoleBinding" || obj.Kind == "ClusterRoleBinding" {
+			subjects, _ := obj.Content["subjects"].([]interface{})
+			for _, s := range subjects {
+				subject, ok := s.(map[string]interface{})
+				if !ok || subject["kind"] != "ServiceAccount" {
+					continue
+				}
+				if ns, _ := subject["namespace"].(string); ns == "" || ns == previous || previous == "" {
+					subject["namespace"] = namespace
+				}
+			}
+		}
+	}
+}
+
+// podTemplate returns the pod template of obj, or nil if it has none
+func podTemplate(obj *Object) map[string]interface{} {
+	if _, ok := podTemplateKinds[obj.Kind]; !ok {
+		return nil
+	}
+	spec, ok := obj.Content["spec"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	template, _ := spec["template"].(map[string]interface{})
+	return template
+}
+
+func addLabels(content map[string]interface{}, labels map[string]string) {
+	existing := child(child(content, "metadata"), "labels")
+	for k, v := range labels {
+		existing[k] = v
+	}
+}
+
+func setResources(template map[string]interface{}, r *Resources) {
+	spec, ok := template["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _ := spec[key].([]interface{})
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			resources := child(container, "resources")
+			if len(r.Limits) > 0 {
+				limits := child(resources, "limits")
+				for k, v := range r.Limits {
+					limits[k] = v
+				}
+			}
+			if len(r.Requests) > 0 {
+				requests := child(resources, "requests")
+				for k, v := range r.Requests {
+					requests[k] = v
+				}
+			}
+		}
+	}
+}
+
+// child returns m[key], creating it if it is missing or not a map
+func child(m map[string]interface{}, key string) map[string]interface{} {
+	c, ok := m[key].(map[string]interface{})
+	if !ok {
+		c = make(map[string]interface{})
+		m[key] = c
+	}
+	return c
+}
+
+// marshal encodes a single object as YAML
+func marshal(obj *Object) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(obj.Content); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", obj, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
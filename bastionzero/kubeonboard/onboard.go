@@ -0,0 +1,123 @@
+// Package kubeonboard onboards Kubernetes clusters as BastionZero Cluster
+// targets.
+//
+// Generate calls TargetsService.GenerateKubeYAML, parses the returned
+// manifest into Objects and applies namespace, label and resource overrides.
+// The result can be written as plain YAML or as a Kustomize base. Once the
+// manifest has been applied to the cluster, WaitForCluster waits for the
+// agent to register and come Online.
+//
+// The manifest embeds the agent's activation token. Nothing in this package
+// logs it: Manifest and Object only print their kinds and names, errors are
+// redacted, and written files are only readable by their owner.
+package kubeonboard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+const (
+	defaultRegisterTimeout = 10 * time.Minute
+	defaultPollInterval    = 5 * time.Second
+)
+
+// Generate requests the agent manifest for a new cluster and applies the
+// overrides to it. The request's Namespace and Labels are sent to
+// BastionZero as well, so overrides is only needed for changes the API does
+// not support (e.g. resource limits).
+func Generate(ctx context.Context, client *bastionzero.Client, request *targets.GenerateActivationTokenAndYamlRequest, overrides *Overrides) (*Manifest, error) {
+	response, _, err := client.Targets.GenerateKubeYAML(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate agent manifest: %w", err)
+	}
+
+	manifest, err := ParseManifest(response.YAML, response.ActivationToken)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Apply(overrides)
+	return manifest, nil
+}
+
+// WaitOptions specifies the optional parameters to WaitForCluster
+type WaitOptions struct {
+	// Timeout bounds the time spent waiting for the target to register and
+	// come Online. Defaults to 10 minutes.
+	Timeout time.Duration
+	// PollInterval is the time between checks for the target's
+	// registration. Defaults to 5 seconds.
+	PollInterval time.Duration
+}
+
+// WaitForCluster waits for the Cluster target with the given name to
+// register in the environment and come Online. environmentID may be empty if
+// the name is unique across environments. Transient errors (network errors
+// and 429 or 5xx responses) are retried until the timeout.
+func WaitForCluster(ctx context.Context, client *bastionzero.Client, name string, environmentID string, opts *WaitOptions) (*targets.ClusterTarget, error) {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultRegisterTimeout
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		targetID string
+		lastErr  error
+	)
+	for targetID == "" {
+		list, _, err := client.Targets.ListClusterTargets(ctx)
+		if err != nil && ctx.Err() == nil && !apierror.IsTransient(err) {
+			return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+		}
+		if ctx.Err() == nil {
+			lastErr = err
+		}
+		for _, t := range list {
+			if t.Name == name && (environmentID == "" || t.EnvironmentID == environmentID) {
+				targetID = t.ID
+				break
+			}
+		}
+		if targetID != "" {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("cluster %s did not register (last error: %s): %w", name, lastErr, ctx.Err())
+			}
+			return nil, fmt.Errorf("cluster %s did not register: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	target, err := client.Targets.WaitForTargetStatus(ctx, targetID, targetstatus.Online, &targets.WaitForTargetStatusOptions{
+		TargetType:      targettype.Cluster,
+		InitialInterval: interval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return target.(*targets.ClusterTarget), nil
+}
@@ -0,0 +1,113 @@
+package kubeonboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File permissions of written manifests. They contain the activation token,
+// so they are only readable by their owner.
+const (
+	fileMode = 0o600
+	dirMode  = 0o700
+)
+
+// KustomizationFile is the name of the kustomization written by
+// WriteKustomize
+const KustomizationFile = "kustomization.yaml"
+
+// WriteYAML writes the manifest to w as a multi-document YAML stream
+func (m *Manifest) WriteYAML(w io.Writer) error {
+	for i, obj := range m.Objects {
+		data, err := marshal(obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the manifest to path as a multi-document YAML stream. The
+// file is only readable by its owner.
+func (m *Manifest) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+	if err := m.WriteYAML(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteKustomize writes each object to its own file in dir, along with a
+// kustomization.yaml listing them. It returns the paths of the written files.
+func (m *Manifest) WriteKustomize(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, err
+	}
+
+	var written, resources []string
+	for i, obj := range m.Objects {
+		data, err := marshal(obj)
+		if err != nil {
+			return written, err
+		}
+		name := fmt.Sprintf("%02d-%s-%s.yaml", i, strings.ToLower(obj.Kind), fileSafe(obj.Name))
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, fileMode); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+		resources = append(resources, name)
+	}
+
+	kustomization := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return written, err
+	}
+	path := filepath.Join(dir, KustomizationFile)
+	if err := os.WriteFile(path, data, fileMode); err != nil {
+		return written, err
+	}
+	return append(written, path), nil
+}
+
+// fileSafe replaces characters that are not safe in file names
+func fileSafe(s string) string {
+	if s == "" {
+		return "unnamed"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,209 @@
+package patch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/environments"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+)
+
+// ConflictError is returned by the Apply functions when the server copy of an
+// object changed since the current copy was read
+type ConflictError struct {
+	// ID is the ID of the object
+	ID string
+	// Fields lists the JSON names of the fields that changed on the server
+	Fields []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s was modified concurrently (changed fields: %s)", e.ID, strings.Join(e.Fields, ", "))
+}
+
+// apply re-fetches the object, fails with a *ConflictError if it no longer
+// matches current, and otherwise sends request. It returns the object after
+// the modification.
+func apply[T any, R any](
+	ctx context.Context,
+	id string,
+	current *T,
+	request *R,
+	s spec,
+	get func(ctx context.Context, id string) (*T, error),
+	modify func(ctx context.Context, id string, request *R) error,
+) (*T, error) {
+	latest, err := get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-fetch %s: %w", id, err)
+	}
+	changed, err := modifiedFields(current, latest, request, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		return nil, &ConflictError{ID: id, Fields: changed}
+	}
+
+	if err := modify(ctx, id, request); err != nil {
+		return nil, err
+	}
+	return get(ctx, id)
+}
+
+// ApplyBzeroTarget modifies the target from current to desired. It returns the
+// modified target and whether anything changed. Nothing is sent if current and
+// desired do not differ. Fails with a *ConflictError if the target changed on
+// the server since current was read.
+func ApplyBzeroTarget(ctx context.Context, client *bastionzero.Client, current, desired *targets.BzeroTarget) (*targets.BzeroTarget, bool, error) {
+	request, changed, err := BzeroTarget(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, bzeroTargetSpec,
+		func(ctx context.Context, id string) (*targets.BzeroTarget, error) {
+			t, _, err := client.Targets.GetBzeroTarget(ctx, id)
+			return t, err
+		},
+		func(ctx context.Context, id string, r *targets.ModifyBzeroTargetRequest) error {
+			_, _, err := client.Targets.ModifyBzeroTarget(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyClusterTarget modifies the target from current to desired. See
+// ApplyBzeroTarget.
+func ApplyClusterTarget(ctx context.Context, client *bastionzero.Client, current, desired *targets.ClusterTarget) (*targets.ClusterTarget, bool, error) {
+	request, changed, err := ClusterTarget(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, clusterTargetSpec,
+		func(ctx context.Context, id string) (*targets.ClusterTarget, error) {
+			t, _, err := client.Targets.GetClusterTarget(ctx, id)
+			return t, err
+		},
+		func(ctx context.Context, id string, r *targets.ModifyClusterTargetRequest) error {
+			_, _, err := client.Targets.ModifyClusterTarget(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyDatabaseTarget modifies the target from current to desired. See
+// ApplyBzeroTarget.
+func ApplyDatabaseTarget(ctx context.Context, client *bastionzero.Client, current, desired *targets.DatabaseTarget) (*targets.DatabaseTarget, bool, error) {
+	request, changed, err := DatabaseTarget(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, databaseTargetSpec,
+		func(ctx context.Context, id string) (*targets.DatabaseTarget, error) {
+			t, _, err := client.Targets.GetDatabaseTarget(ctx, id)
+			return t, err
+		},
+		func(ctx context.Context, id string, r *targets.ModifyDatabaseTargetRequest) error {
+			_, _, err := client.Targets.ModifyDatabaseTarget(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyWebTarget modifies the target from current to desired. See
+// ApplyBzeroTarget.
+func ApplyWebTarget(ctx context.Context, client *bastionzero.Client, current, desired *targets.WebTarget) (*targets.WebTarget, bool, error) {
+	request, changed, err := WebTarget(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, webTargetSpec,
+		func(ctx context.Context, id string) (*targets.WebTarget, error) {
+			t, _, err := client.Targets.GetWebTarget(ctx, id)
+			return t, err
+		},
+		func(ctx context.Context, id string, r *targets.ModifyWebTargetRequest) error {
+			_, _, err := client.Targets.ModifyWebTarget(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyEnvironment modifies the environment from current to desired. See
+// ApplyBzeroTarget.
+func ApplyEnvironment(ctx context.Context, client *bastionzero.Client, current, desired *environments.Environment) (*environments.Environment, bool, error) {
+	request, changed, err := Environment(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, environmentSpec,
+		func(ctx context.Context, id string) (*environments.Environment, error) {
+			e, _, err := client.Environments.GetEnvironment(ctx, id)
+			return e, err
+		},
+		func(ctx context.Context, id string, r *environments.ModifyEnvironmentRequest) error {
+			_, err := client.Environments.ModifyEnvironment(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyTargetConnectPolicy modifies the policy from current to desired. See
+// ApplyBzeroTarget.
+func ApplyTargetConnectPolicy(ctx context.Context, client *bastionzero.Client, current, desired *policies.TargetConnectPolicy) (*policies.TargetConnectPolicy, bool, error) {
+	request, changed, err := TargetConnectPolicy(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, policySpec,
+		func(ctx context.Context, id string) (*policies.TargetConnectPolicy, error) {
+			p, _, err := client.Policies.GetTargetConnectPolicy(ctx, id)
+			return p, err
+		},
+		func(ctx context.Context, id string, r *policies.TargetConnectPolicy) error {
+			_, _, err := client.Policies.ModifyTargetConnectPolicy(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyKubernetesPolicy modifies the policy from current to desired. See
+// ApplyBzeroTarget.
+func ApplyKubernetesPolicy(ctx context.Context, client *bastionzero.Client, current, desired *policies.KubernetesPolicy) (*policies.KubernetesPolicy, bool, error) {
+	request, changed, err := KubernetesPolicy(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, policySpec,
+		func(ctx context.Context, id string) (*policies.KubernetesPolicy, error) {
+			p, _, err := client.Policies.GetKubernetesPolicy(ctx, id)
+			return p, err
+		},
+		func(ctx context.Context, id string, r *policies.KubernetesPolicy) error {
+			_, _, err := client.Policies.ModifyKubernetesPolicy(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
+
+// ApplyProxyPolicy modifies the policy from current to desired. See
+// ApplyBzeroTarget.
+func ApplyProxyPolicy(ctx context.Context, client *bastionzero.Client, current, desired *policies.ProxyPolicy) (*policies.ProxyPolicy, bool, error) {
+	request, changed, err := ProxyPolicy(current, desired)
+	if err != nil || !changed {
+		return current, false, err
+	}
+	result, err := apply(ctx, current.ID, current, request, policySpec,
+		func(ctx context.Context, id string) (*policies.ProxyPolicy, error) {
+			p, _, err := client.Policies.GetProxyPolicy(ctx, id)
+			return p, err
+		},
+		func(ctx context.Context, id string, r *policies.ProxyPolicy) error {
+			_, _, err := client.Policies.ModifyProxyPolicy(ctx, id, r)
+			return err
+		})
+	return result, err == nil, err
+}
@@ -0,0 +1,286 @@
+// Package patch computes minimal Modify requests from the current and desired
+// state of an object.
+//
+// Instead of building a ModifyDatabaseTargetRequest (or a partially populated
+// TargetConnectPolicy) by hand, fetch the object, copy and edit it, and let
+// this package populate only the fields that changed:
+//
+//	current, _, _ := client.Targets.GetDatabaseTarget(ctx, id)
+//	desired := *current
+//	desired.RemoteHost = "db.internal"
+//	request, changed, err := patch.DatabaseTarget(current, &desired)
+//
+// The Apply functions additionally implement optimistic concurrency: they
+// re-fetch the object before modifying it and fail with a *ConflictError if
+// any of the fields that can be modified changed on the server since current
+// was read.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/environments"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+)
+
+// spec describes how the fields of a Modify request map to the fields of the
+// object it modifies
+type spec struct {
+	// aliases maps a request field's JSON name to the JSON name of the
+	// corresponding object field when they differ
+	aliases map[string]string
+	// skip lists request fields (by JSON name) that are never patched
+	skip []string
+	// clearable lists request fields (by JSON name) that are sent with their
+	// empty value when the desired object leaves them unset, so that they
+	// can be cleared
+	clearable []string
+}
+
+var (
+	bzeroTargetSpec    = spec{aliases: map[string]string{"targetName": "name"}}
+	clusterTargetSpec  = spec{}
+	databaseTargetSpec = spec{aliases: map[string]string{"targetName": "name"}}
+	webTargetSpec      = spec{aliases: map[string]string{"targetName": "name"}}
+	environmentSpec    = spec{clearable: []string{"description"}}
+	// A policy's ID is not mutable and its TimeExpires can only be set when
+	// the policy is created
+	policySpec = spec{
+		skip: []string{"id", "timeExpires"},
+		clearable: []string{
+			"description", "subjects", "groups", "environments", "targets",
+			"targetUsers", "verbs", "clusters", "clusterUsers", "clusterGroups",
+		},
+	}
+)
+
+// BzeroTarget returns the request that modifies current into desired and
+// whether anything changed
+func BzeroTarget(current, desired *targets.BzeroTarget) (*targets.ModifyBzeroTargetRequest, bool, error) {
+	request := new(targets.ModifyBzeroTargetRequest)
+	changed, err := compute(current, desired, request, bzeroTargetSpec)
+	return request, changed, err
+}
+
+// ClusterTarget returns the request that modifies current into desired and
+// whether anything changed
+func ClusterTarget(current, desired *targets.ClusterTarget) (*targets.ModifyClusterTargetRequest, bool, error) {
+	request := new(targets.ModifyClusterTargetRequest)
+	changed, err := compute(current, desired, request, clusterTargetSpec)
+	return request, changed, err
+}
+
+// DatabaseTarget returns the request that modifies current into desired and
+// whether anything changed
+func DatabaseTarget(current, desired *targets.DatabaseTarget) (*targets.ModifyDatabaseTargetRequest, bool, error) {
+	request := new(targets.ModifyDatabaseTargetRequest)
+	changed, err := compute(current, desired, request, databaseTargetSpec)
+	return request, changed, err
+}
+
+// WebTarget returns the request that modifies current into desired and
+// whether anything changed
+func WebTarget(current, desired *targets.WebTarget) (*targets.ModifyWebTargetRequest, bool, error) {
+	request := new(targets.ModifyWebTargetRequest)
+	changed, err := compute(current, desired, request, webTargetSpec)
+	return request, changed, err
+}
+
+// Environment returns the request that modifies current into desired and
+// whether anything changed
+func Environment(current, desired *environments.Environment) (*environments.ModifyEnvironmentRequest, bool, error) {
+	request := new(environments.ModifyEnvironmentRequest)
+	changed, err := compute(current, desired, request, environmentSpec)
+	return request, changed, err
+}
+
+// TargetConnectPolicy returns a policy with only the fields that differ
+// between current and desired set, and whether anything changed
+func TargetConnectPolicy(current, desired *policies.TargetConnectPolicy) (*policies.TargetConnectPolicy, bool, error) {
+	request := new(policies.TargetConnectPolicy)
+	changed, err := compute(current, desired, request, policySpec)
+	return request, changed, err
+}
+
+// KubernetesPolicy returns a policy with only the fields that differ between
+// current and desired set, and whether anything changed
+func KubernetesPolicy(current, desired *policies.KubernetesPolicy) (*policies.KubernetesPolicy, bool, error) {
+	request := new(policies.KubernetesPolicy)
+	changed, err := compute(current, desired, request, policySpec)
+	return request, changed, err
+}
+
+// ProxyPolicy returns a policy with only the fields that differ between
+// current and desired set, and whether anything changed
+func ProxyPolicy(current, desired *policies.ProxyPolicy) (*policies.ProxyPolicy, bool, error) {
+	request := new(policies.ProxyPolicy)
+	changed, err := compute(current, desired, request, policySpec)
+	return request, changed, err
+}
+
+// compute sets each field of request (a pointer to a struct) whose
+// corresponding field differs between current and desired to the desired
+// value. Fields are matched by JSON name. A clearable field that is set on
+// current but unset on desired is set to its explicit empty value.
+func compute(current, desired, request interface{}, s spec) (bool, error) {
+	currentFields, err := toFields(current)
+	if err != nil {
+		return false, err
+	}
+	desiredFields, err := toFields(desired)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, f := range requestFields(reflect.ValueOf(request).Elem(), s) {
+		desiredValue, ok := desiredFields[f.objectName]
+		if !ok || jsonEqual(desiredValue, nil) {
+			if contains(s.clearable, f.name) && !jsonEmpty(currentFields[f.objectName]) && f.value.Kind() == reflect.Pointer {
+				f.value.Set(emptyPointer(f.value.Type()))
+				changed = true
+			}
+			continue
+		}
+		if jsonEqual(currentFields[f.objectName], desiredValue) {
+			continue
+		}
+
+		target := reflect.New(f.value.Type())
+		if err := json.Unmarshal(desiredValue, target.Interface()); err != nil {
+			return false, fmt.Errorf("failed to set %s: %w", f.name, err)
+		}
+		f.value.Set(target.Elem())
+		changed = true
+	}
+	return changed, nil
+}
+
+// modifiedFields returns the JSON names of the object fields that a request
+// built with s can modify and that differ between a and b
+func modifiedFields(a, b interface{}, request interface{}, s spec) ([]string, error) {
+	aFields, err := toFields(a)
+	if err != nil {
+		return nil, err
+	}
+	bFields, err := toFields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range requestFields(reflect.ValueOf(request).Elem(), s) {
+		if !jsonEqual(aFields[f.objectName], bFields[f.objectName]) {
+			names = append(names, f.objectName)
+		}
+	}
+	return names, nil
+}
+
+type requestField struct {
+	// name is the JSON name of the request field
+	name string
+	// objectName is the JSON name of the corresponding object field
+	objectName string
+	value      reflect.Value
+}
+
+// requestFields lists the settable fields of v, including fields promoted from
+// embedded structs
+func requestFields(v reflect.Value, s spec) []requestField {
+	var fields []requestField
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, requestFields(v.Field(i), s)...)
+			continue
+		}
+		if !f.IsExported() || name == "" || name == "-" || contains(s.skip, name) {
+			continue
+		}
+
+		objectName := name
+		if alias, ok := s.aliases[name]; ok {
+			objectName = alias
+		}
+		fields = append(fields, requestField{name: name, objectName: objectName, value: v.Field(i)})
+	}
+	return fields
+}
+
+// toFields returns the top-level JSON fields of v
+func toFields(v interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// jsonEqual compares two JSON values semantically. Missing values and null are
+// equal.
+func jsonEqual(a, b json.RawMessage) bool {
+	var av, bv interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &av); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &bv); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// jsonEmpty returns true if a JSON value is missing, null, or an empty string,
+// array or object
+func jsonEmpty(a json.RawMessage) bool {
+	if len(a) == 0 {
+		return true
+	}
+	var v interface{}
+	if err := json.Unmarshal(a, &v); err != nil {
+		return false
+	}
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// emptyPointer returns a pointer of type t to an empty value. Slices are
+// allocated so that they are sent as [] rather than null.
+func emptyPointer(t reflect.Type) reflect.Value {
+	p := reflect.New(t.Elem())
+	if t.Elem().Kind() == reflect.Slice {
+		p.Elem().Set(reflect.MakeSlice(t.Elem(), 0, 0))
+	}
+	return p
+}
+
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package policylint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+)
+
+// FetchPolicies lists every policy in the organization
+func FetchPolicies(ctx context.Context, client *bastionzero.Client) (*Policies, error) {
+	p := new(Policies)
+	var err error
+
+	if p.TargetConnect, _, err = client.Policies.ListTargetConnectPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list target connect policies: %w", err)
+	}
+	if p.Kubernetes, _, err = client.Policies.ListKubernetesPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list Kubernetes policies: %w", err)
+	}
+	if p.Proxy, _, err = client.Policies.ListProxyPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list proxy policies: %w", err)
+	}
+	if p.JIT, _, err = client.Policies.ListJITPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list JIT policies: %w", err)
+	}
+	if p.OrganizationControls, _, err = client.Policies.ListOrganizationControlsPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list organization controls policies: %w", err)
+	}
+	if p.SessionRecording, _, err = client.Policies.ListSessionRecordingPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list session recording policies: %w", err)
+	}
+
+	return p, nil
+}
+
+// FetchInventory lists every target, environment, subject and group in the
+// organization
+func FetchInventory(ctx context.Context, client *bastionzero.Client) (*Inventory, error) {
+	inv := &Inventory{
+		TargetEnvironments: make(map[string]string),
+		EnvironmentIDs:     make(map[string]struct{}),
+		SubjectIDs:         make(map[string]struct{}),
+		GroupIDs:           make(map[string]struct{}),
+	}
+
+	bzeroTargets, _, err := client.Targets.ListBzeroTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Bzero targets: %w", err)
+	}
+	for _, t := range bzeroTargets {
+		inv.TargetEnvironments[t.ID] = t.EnvironmentID
+	}
+
+	clusterTargets, _, err := client.Targets.ListClusterTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+	}
+	for _, t := range clusterTargets {
+		inv.TargetEnvironments[t.ID] = t.EnvironmentID
+	}
+
+	dbTargets, _, err := client.Targets.ListDatabaseTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Database targets: %w", err)
+	}
+	for _, t := range dbTargets {
+		inv.TargetEnvironments[t.ID] = t.EnvironmentID
+	}
+
+	webTargets, _, err := client.Targets.ListWebTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Web targets: %w", err)
+	}
+	for _, t := range webTargets {
+		inv.TargetEnvironments[t.ID] = t.EnvironmentID
+	}
+
+	dacs, _, err := client.Targets.ListDynamicAccessConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dynamic access configurations: %w", err)
+	}
+	for _, d := range dacs {
+		inv.TargetEnvironments[d.ID] = d.EnvironmentId
+	}
+
+	envs, _, err := client.Environments.ListEnvironments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, e := range envs {
+		inv.EnvironmentIDs[e.ID] = struct{}{}
+	}
+
+	subjects, _, err := client.Subjects.ListSubjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	for _, s := range subjects {
+		inv.SubjectIDs[s.ID] = struct{}{}
+	}
+
+	groups, _, err := client.Organization.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, g := range groups {
+		inv.GroupIDs[g.ID] = struct{}{}
+	}
+
+	return inv, nil
+}
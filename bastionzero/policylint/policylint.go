@@ -0,0 +1,192 @@
+// Package policylint reports risky, stale and broken BastionZero policies.
+//
+// Lint runs a fixed set of rules against a set of policies and an inventory of
+// the objects that currently exist in the organization. Use FetchPolicies and
+// FetchInventory to build both from the live API.
+package policylint
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/policylint/severity"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+)
+
+// Rule identifies the check that produced a finding
+type Rule string
+
+const (
+	// MissingReference reports a policy that references a target,
+	// environment, subject or group that no longer exists
+	MissingReference Rule = "missing-reference"
+	// Expired reports a policy whose TimeExpires is in the past
+	Expired Rule = "expired"
+	// JITMissingChild reports a JIT policy whose child policy no longer exists
+	JITMissingChild Rule = "jit-missing-child"
+	// JITIneligibleChild reports a JIT policy whose child policy is not a
+	// target connect, Kubernetes or proxy policy
+	JITIneligibleChild Rule = "jit-ineligible-child"
+	// BroadGrant reports a policy that grants a privileged target user,
+	// cluster user or cluster group
+	BroadGrant Rule = "broad-grant"
+	// Duplicate reports a policy that is identical to another policy of the
+	// same type
+	Duplicate Rule = "duplicate"
+	// Shadowed reports a policy whose access is entirely covered by another
+	// policy of the same type
+	Shadowed Rule = "shadowed"
+)
+
+// Finding is a single problem found by the linter
+type Finding struct {
+	Rule       Rule                  `json:"rule"`
+	Severity   severity.Severity     `json:"severity"`
+	PolicyID   string                `json:"policyId"`
+	PolicyName string                `json:"policyName"`
+	PolicyType policytype.PolicyType `json:"policyType"`
+	Message    string                `json:"message"`
+	// RelatedPolicyID is the ID of the other policy involved in the finding
+	// (e.g. the policy that a duplicate or shadowed policy matches). Empty if
+	// the finding only concerns a single policy.
+	RelatedPolicyID string `json:"relatedPolicyId,omitempty"`
+}
+
+// Policies is the set of policies to lint
+type Policies struct {
+	TargetConnect        []policies.TargetConnectPolicy        `json:"targetConnect"`
+	Kubernetes           []policies.KubernetesPolicy           `json:"kubernetes"`
+	Proxy                []policies.ProxyPolicy                `json:"proxy"`
+	JIT                  []policies.JITPolicy                  `json:"jit"`
+	OrganizationControls []policies.OrganizationControlsPolicy `json:"organizationControls"`
+	SessionRecording     []policies.SessionRecordingPolicy     `json:"sessionRecording"`
+}
+
+// Inventory is the set of objects that currently exist in the organization.
+// Policies that reference IDs not present in the inventory are reported by the
+// MissingReference rule.
+type Inventory struct {
+	// TargetEnvironments maps every existing target ID (including DACs) to the
+	// ID of the environment it belongs to
+	TargetEnvironments map[string]string
+	EnvironmentIDs     map[string]struct{}
+	SubjectIDs         map[string]struct{}
+	GroupIDs           map[string]struct{}
+}
+
+// Options configures the linter
+type Options struct {
+	// Now is the time used to decide whether a policy has expired. Defaults to
+	// time.Now() if zero.
+	Now time.Time
+	// BroadTargetUsers are target users that should not be granted by target
+	// connect policies without review
+	BroadTargetUsers []string
+	// BroadDatabaseUsers are target users that should not be granted by proxy
+	// policies without review
+	BroadDatabaseUsers []string
+	// BroadClusterUsers are Kubernetes users that should not be granted by
+	// Kubernetes policies without review
+	BroadClusterUsers []string
+	// BroadClusterGroups are Kubernetes groups that should not be granted by
+	// Kubernetes policies without review
+	BroadClusterGroups []string
+	// DisabledRules are rules whose findings are dropped from the report
+	DisabledRules []Rule
+	// Severities overrides the default severity of a rule
+	Severities map[Rule]severity.Severity
+}
+
+// DefaultOptions returns the options used when Lint is passed nil options
+func DefaultOptions() *Options {
+	return &Options{
+		BroadTargetUsers:   []string{"root"},
+		BroadClusterGroups: []string{"system:masters"},
+	}
+}
+
+// defaultSeverities is the severity of each rule unless overridden by
+// Options.Severities
+var defaultSeverities = map[Rule]severity.Severity{
+	MissingReference:   severity.Error,
+	Expired:            severity.Warning,
+	JITMissingChild:    severity.Error,
+	JITIneligibleChild: severity.Error,
+	BroadGrant:         severity.Warning,
+	Duplicate:          severity.Warning,
+	Shadowed:           severity.Info,
+}
+
+// Lint runs every rule against p and returns a report of the findings. If inv
+// is nil, the MissingReference rule is skipped. If opts is nil,
+// DefaultOptions() is used.
+func Lint(p *Policies, inv *Inventory, opts *Options) *Report {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	l := &linter{
+		opts:     opts,
+		now:      now,
+		inv:      inv,
+		policies: normalize(p),
+		disabled: make(map[Rule]struct{}, len(opts.DisabledRules)),
+	}
+	for _, r := range opts.DisabledRules {
+		l.disabled[r] = struct{}{}
+	}
+
+	if inv != nil {
+		l.checkMissingReferences()
+	}
+	l.checkExpired()
+	l.checkJITChildren(p.JIT)
+	l.checkBroadGrants()
+	l.checkDuplicatesAndShadowed()
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		if a.PolicyName != b.PolicyName {
+			return a.PolicyName < b.PolicyName
+		}
+		return a.Rule < b.Rule
+	})
+
+	return &Report{GeneratedAt: now, Findings: l.findings}
+}
+
+type linter struct {
+	opts     *Options
+	now      time.Time
+	inv      *Inventory
+	policies []*lintPolicy
+	disabled map[Rule]struct{}
+	findings []Finding
+}
+
+func (l *linter) report(rule Rule, p *lintPolicy, related string, msg string) {
+	if _, ok := l.disabled[rule]; ok {
+		return
+	}
+	sev, ok := l.opts.Severities[rule]
+	if !ok {
+		sev = defaultSeverities[rule]
+	}
+	l.findings = append(l.findings, Finding{
+		Rule:            rule,
+		Severity:        sev,
+		PolicyID:        p.id,
+		PolicyName:      p.name,
+		PolicyType:      p.policyType,
+		Message:         msg,
+		RelatedPolicyID: related,
+	})
+}
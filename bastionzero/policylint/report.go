@@ -0,0 +1,86 @@
+package policylint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/policylint/severity"
+)
+
+// Report is the result of linting a set of policies
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Findings    []Finding `json:"findings"`
+}
+
+// Summary returns the number of findings per severity
+func (r *Report) Summary() map[severity.Severity]int {
+	summary := make(map[severity.Severity]int, len(severity.SeverityValues()))
+	for _, s := range severity.SeverityValues() {
+		summary[s] = 0
+	}
+	for _, f := range r.Findings {
+		summary[f.Severity]++
+	}
+	return summary
+}
+
+// HasFindings returns true if the report contains at least one finding at or
+// above the given severity
+func (r *Report) HasFindings(atLeast severity.Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(atLeast) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns the process exit status to use when running the linter in
+// CI: 1 if the report contains at least one finding at or above failOn,
+// otherwise 0.
+func (r *Report) ExitCode(failOn severity.Severity) int {
+	if r.HasFindings(failOn) {
+		return 1
+	}
+	return 0
+}
+
+// WriteJSON writes the report to w as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := struct {
+		GeneratedAt time.Time                 `json:"generatedAt"`
+		Summary     map[severity.Severity]int `json:"summary"`
+		Findings    []Finding                 `json:"findings"`
+	}{
+		GeneratedAt: r.GeneratedAt,
+		Summary:     r.Summary(),
+		Findings:    r.Findings,
+	}
+	if out.Findings == nil {
+		out.Findings = []Finding{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteText writes the report to w as a human readable table
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tTYPE\tPOLICY\tMESSAGE")
+	for _, f := range r.Findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.PolicyType, f.PolicyName, f.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	summary := r.Summary()
+	_, err := fmt.Fprintf(w, "\n%d error(s), %d warning(s), %d info\n", summary[severity.Error], summary[severity.Warning], summary[severity.Info])
+	return err
+}
@@ -0,0 +1,369 @@
+package policylint
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+)
+
+// set is a set of strings
+type set map[string]struct{}
+
+func newSet(elems ...string) set {
+	s := make(set, len(elems))
+	for _, e := range elems {
+		s[e] = struct{}{}
+	}
+	return s
+}
+
+func (s set) has(e string) bool {
+	_, ok := s[e]
+	return ok
+}
+
+func (s set) equal(o set) bool {
+	return len(s) == len(o) && s.subsetOf(o)
+}
+
+func (s set) subsetOf(o set) bool {
+	for e := range s {
+		if !o.has(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s set) sorted() []string {
+	elems := make([]string, 0, len(s))
+	for e := range s {
+		elems = append(elems, e)
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+// lintPolicy is a policy of any type normalized into sets so that policies can
+// be compared with one another
+type lintPolicy struct {
+	id          string
+	name        string
+	policyType  policytype.PolicyType
+	timeExpires *types.Timestamp
+
+	subjects     set
+	groups       set
+	targets      set
+	environments set
+	// grants is what the policy allows once a principal is in scope (e.g.
+	// target users, verbs, cluster groups, child policies)
+	grants set
+	// settings encodes the scalar attributes of the policy that must match for
+	// two policies to be considered duplicates
+	settings string
+
+	targetUsers   []string
+	clusterUsers  []string
+	clusterGroups []string
+}
+
+// isAccessPolicy returns true if the policy grants access to targets
+func (p *lintPolicy) isAccessPolicy() bool {
+	switch p.policyType {
+	case policytype.TargetConnect, policytype.Kubernetes, policytype.Proxy:
+		return true
+	default:
+		return false
+	}
+}
+
+func newLintPolicy(p policies.PolicyInterface) *lintPolicy {
+	lp := &lintPolicy{
+		id:           p.GetID(),
+		name:         p.GetName(),
+		policyType:   p.GetPolicyType(),
+		timeExpires:  p.GetTimeExpires(),
+		subjects:     newSet(),
+		groups:       newSet(),
+		targets:      newSet(),
+		environments: newSet(),
+		grants:       newSet(),
+	}
+	for _, s := range p.GetSubjects() {
+		lp.subjects[s.ID] = struct{}{}
+	}
+	for _, g := range p.GetGroups() {
+		lp.groups[g.ID] = struct{}{}
+	}
+	return lp
+}
+
+func normalize(p *Policies) []*lintPolicy {
+	var result []*lintPolicy
+
+	for i := range p.TargetConnect {
+		tc := &p.TargetConnect[i]
+		lp := newLintPolicy(tc)
+		for _, t := range tc.GetTargets() {
+			lp.targets[t.ID] = struct{}{}
+		}
+		for _, e := range tc.GetEnvironments() {
+			lp.environments[e.ID] = struct{}{}
+		}
+		lp.targetUsers = tc.GetTargetUsersAsStringList()
+		for _, u := range lp.targetUsers {
+			lp.grants["user:"+u] = struct{}{}
+		}
+		for _, v := range tc.GetVerbsAsStringList() {
+			lp.grants["verb:"+v] = struct{}{}
+		}
+		result = append(result, lp)
+	}
+
+	for i := range p.Kubernetes {
+		k := &p.Kubernetes[i]
+		lp := newLintPolicy(k)
+		for _, c := range k.GetClusters() {
+			lp.targets[c.ID] = struct{}{}
+		}
+		for _, e := range k.GetEnvironments() {
+			lp.environments[e.ID] = struct{}{}
+		}
+		for _, u := range k.GetClusterUsers() {
+			lp.clusterUsers = append(lp.clusterUsers, u.Name)
+			lp.grants["clusterUser:"+u.Name] = struct{}{}
+		}
+		for _, g := range k.GetClusterGroups() {
+			lp.clusterGroups = append(lp.clusterGroups, g.Name)
+			lp.grants["clusterGroup:"+g.Name] = struct{}{}
+		}
+		result = append(result, lp)
+	}
+
+	for i := range p.Proxy {
+		px := &p.Proxy[i]
+		lp := newLintPolicy(px)
+		for _, t := range px.GetTargets() {
+			lp.targets[t.ID] = struct{}{}
+		}
+		for _, e := range px.GetEnvironments() {
+			lp.environments[e.ID] = struct{}{}
+		}
+		lp.targetUsers = px.GetTargetUsersAsStringList()
+		for _, u := range lp.targetUsers {
+			lp.grants["user:"+u] = struct{}{}
+		}
+		result = append(result, lp)
+	}
+
+	for i := range p.JIT {
+		j := &p.JIT[i]
+		lp := newLintPolicy(j)
+		for _, c := range j.GetChildPolicies() {
+			lp.grants["child:"+c.ID] = struct{}{}
+		}
+		lp.settings = fmt.Sprintf("automaticallyApproved=%t,duration=%d", j.GetAutomaticallyApproved(), j.GetDuration())
+		result = append(result, lp)
+	}
+
+	for i := range p.OrganizationControls {
+		oc := &p.OrganizationControls[i]
+		lp := newLintPolicy(oc)
+		lp.settings = fmt.Sprintf("mfaEnabled=%t,mfaDuration=%d", oc.GetMFAEnabled(), oc.GetMFADuration())
+		result = append(result, lp)
+	}
+
+	for i := range p.SessionRecording {
+		sr := &p.SessionRecording[i]
+		lp := newLintPolicy(sr)
+		lp.settings = fmt.Sprintf("recordInput=%t", sr.GetRecordInput())
+		result = append(result, lp)
+	}
+
+	return result
+}
+
+func (l *linter) checkMissingReferences() {
+	for _, p := range l.policies {
+		for _, id := range p.targets.sorted() {
+			if _, ok := l.inv.TargetEnvironments[id]; !ok {
+				l.report(MissingReference, p, "", fmt.Sprintf("target %s does not exist", id))
+			}
+		}
+		for _, id := range p.environments.sorted() {
+			if _, ok := l.inv.EnvironmentIDs[id]; !ok {
+				l.report(MissingReference, p, "", fmt.Sprintf("environment %s does not exist", id))
+			}
+		}
+		for _, id := range p.subjects.sorted() {
+			if _, ok := l.inv.SubjectIDs[id]; !ok {
+				l.report(MissingReference, p, "", fmt.Sprintf("subject %s does not exist", id))
+			}
+		}
+		for _, id := range p.groups.sorted() {
+			if _, ok := l.inv.GroupIDs[id]; !ok {
+				l.report(MissingReference, p, "", fmt.Sprintf("group %s does not exist", id))
+			}
+		}
+	}
+}
+
+func (l *linter) checkExpired() {
+	for _, p := range l.policies {
+		if p.expired(l.now) {
+			l.report(Expired, p, "", fmt.Sprintf("policy expired at %s", p.timeExpires.UTC().Format(time.RFC3339)))
+		}
+	}
+}
+
+// jitEligibleTypes are the policy types that a JIT policy may grant
+var jitEligibleTypes = map[policytype.PolicyType]struct{}{
+	policytype.TargetConnect: {},
+	policytype.Kubernetes:    {},
+	policytype.Proxy:         {},
+}
+
+func (l *linter) checkJITChildren(jitPolicies []policies.JITPolicy) {
+	byID := make(map[string]*lintPolicy, len(l.policies))
+	for _, p := range l.policies {
+		byID[p.id] = p
+	}
+
+	for i := range jitPolicies {
+		j := &jitPolicies[i]
+		lp := byID[j.ID]
+		if lp == nil {
+			continue
+		}
+		for _, c := range j.GetChildPolicies() {
+			child, ok := byID[c.ID]
+			if !ok {
+				l.report(JITMissingChild, lp, c.ID, fmt.Sprintf("child policy %s does not exist", c.ID))
+				continue
+			}
+			if _, ok := jitEligibleTypes[child.policyType]; !ok {
+				l.report(JITIneligibleChild, lp, c.ID, fmt.Sprintf("child policy %q is a %s policy; only TargetConnect, Kubernetes and Proxy policies can be granted just in time", child.name, child.policyType))
+			}
+		}
+	}
+}
+
+func (l *linter) checkBroadGrants() {
+	check := func(p *lintPolicy, kind string, granted []string, broad []string) {
+		broadSet := newSet(broad...)
+		for _, g := range granted {
+			if broadSet.has(g) {
+				l.report(BroadGrant, p, "", fmt.Sprintf("policy grants %s %q", kind, g))
+			}
+		}
+	}
+
+	for _, p := range l.policies {
+		switch p.policyType {
+		case policytype.TargetConnect:
+			check(p, "target user", p.targetUsers, l.opts.BroadTargetUsers)
+		case policytype.Proxy:
+			check(p, "database user", p.targetUsers, l.opts.BroadDatabaseUsers)
+		case policytype.Kubernetes:
+			check(p, "cluster user", p.clusterUsers, l.opts.BroadClusterUsers)
+			check(p, "cluster group", p.clusterGroups, l.opts.BroadClusterGroups)
+		}
+	}
+}
+
+func (l *linter) checkDuplicatesAndShadowed() {
+	// duplicateOf tracks policies already reported as duplicates so that each
+	// group of identical policies is only reported once per extra copy
+	duplicateOf := make(map[string]string)
+
+	for i, p := range l.policies {
+		for j, q := range l.policies {
+			if i == j || p.policyType != q.policyType || p.settings != q.settings {
+				continue
+			}
+
+			if p.isDuplicateOf(q) {
+				// Report the later policy in the list as the duplicate
+				if j < i {
+					if _, ok := duplicateOf[p.id]; !ok {
+						duplicateOf[p.id] = q.id
+						l.report(Duplicate, p, q.id, fmt.Sprintf("policy is identical to policy %q", q.name))
+					}
+				}
+				continue
+			}
+
+			// A policy without principals grants nothing to shadow, and an
+			// expired policy neither is shadowed nor shadows others (it is
+			// reported as Expired instead)
+			if !p.isAccessPolicy() || !p.hasPrincipals() || p.expired(l.now) || q.expired(l.now) {
+				continue
+			}
+			if p.isShadowedBy(q, l.inv) {
+				l.report(Shadowed, p, q.id, fmt.Sprintf("all access granted by this policy is also granted by policy %q", q.name))
+				break
+			}
+		}
+	}
+}
+
+// hasPrincipals returns true if the policy applies to at least one subject
+// or group
+func (p *lintPolicy) hasPrincipals() bool {
+	return len(p.subjects) > 0 || len(p.groups) > 0
+}
+
+// expired returns true if the policy's TimeExpires is not after now
+func (p *lintPolicy) expired(now time.Time) bool {
+	return p.timeExpires != nil && !p.timeExpires.After(now)
+}
+
+func (p *lintPolicy) isDuplicateOf(q *lintPolicy) bool {
+	return p.subjects.equal(q.subjects) &&
+		p.groups.equal(q.groups) &&
+		p.targets.equal(q.targets) &&
+		p.environments.equal(q.environments) &&
+		p.grants.equal(q.grants) &&
+		sameExpiry(p.timeExpires, q.timeExpires)
+}
+
+// isShadowedBy returns true if q grants at least the same access as p to at
+// least the same principals for at least as long as p
+func (p *lintPolicy) isShadowedBy(q *lintPolicy, inv *Inventory) bool {
+	if !p.subjects.subsetOf(q.subjects) || !p.groups.subsetOf(q.groups) {
+		return false
+	}
+	if !p.grants.subsetOf(q.grants) || !p.environments.subsetOf(q.environments) {
+		return false
+	}
+	for t := range p.targets {
+		if q.targets.has(t) {
+			continue
+		}
+		// A target is also covered if q grants access to its entire
+		// environment
+		if inv == nil {
+			return false
+		}
+		envID, ok := inv.TargetEnvironments[t]
+		if !ok || !q.environments.has(envID) {
+			return false
+		}
+	}
+	if q.timeExpires != nil && (p.timeExpires == nil || q.timeExpires.Before(p.timeExpires.Time)) {
+		return false
+	}
+	return true
+}
+
+func sameExpiry(a, b *types.Timestamp) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,37 @@
+package severity
+
+//go:generate go run github.com/lindell/string-enumer -t Severity -o ./generated.go .
+
+// Severity represents how serious a policy lint finding is
+type Severity string
+
+const (
+	// Info represents a finding that is worth reviewing but is not necessarily
+	// a problem
+	Info Severity = "Info"
+	// Warning represents a finding that likely indicates a risky or stale
+	// policy
+	Warning Severity = "Warning"
+	// Error represents a finding that indicates a broken policy
+	Error Severity = "Error"
+)
+
+// Rank returns the relative ordering of the severity. Higher ranks are more
+// severe. Invalid severities have a rank of 0.
+func (v Severity) Rank() int {
+	switch v {
+	case Info:
+		return 1
+	case Warning:
+		return 2
+	case Error:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AtLeast returns true if v is at least as severe as other
+func (v Severity) AtLeast(other Severity) bool {
+	return v.Rank() >= other.Rank()
+}
@@ -0,0 +1,26 @@
+package paramtype
+
+//go:generate go run github.com/lindell/string-enumer -t ParamType -o ./generated.go .
+
+// ParamType represents the type of a policy template parameter
+type ParamType string
+
+const (
+	// String is a free-form string
+	String ParamType = "String"
+	// StringList is a list of free-form strings (e.g. target users or cluster
+	// groups)
+	StringList ParamType = "StringList"
+	// Group is the name of an IdP group. It must exist in the organization.
+	Group ParamType = "Group"
+	// Environment is the name of an environment. It must exist in the
+	// organization.
+	Environment ParamType = "Environment"
+	// Verbs is a list of target connect verbs
+	Verbs ParamType = "Verbs"
+)
+
+// IsList returns true if values of this type are lists of strings
+func (v ParamType) IsList() bool {
+	return v == StringList || v == Verbs
+}
@@ -0,0 +1,163 @@
+package policytemplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+)
+
+// Instance records a policy that was created from a template
+type Instance struct {
+	PolicyID   string                `json:"policyId"`
+	PolicyType policytype.PolicyType `json:"policyType"`
+	PolicyName string                `json:"policyName"`
+	// Template is the name of the template the policy was rendered from
+	Template string `json:"template"`
+	// Fingerprint is the fingerprint of the template at the time the policy
+	// was last rendered
+	Fingerprint string `json:"fingerprint"`
+	// Values are the parameter values the policy was rendered with
+	Values     Values    `json:"values"`
+	RenderedAt time.Time `json:"renderedAt"`
+}
+
+// Registry tracks which policies were created from which template. It is
+// persisted as JSON with Write and ReadRegistry.
+type Registry struct {
+	Instances []Instance `json:"instances"`
+}
+
+// ReadRegistry reads a registry previously written with Write
+func ReadRegistry(r io.Reader) (*Registry, error) {
+	reg := new(Registry)
+	if err := json.NewDecoder(r).Decode(reg); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// Write writes the registry to w as indented JSON
+func (reg *Registry) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reg)
+}
+
+// InstancesOf returns the instances rendered from the named template
+func (reg *Registry) InstancesOf(templateName string) []Instance {
+	var result []Instance
+	for _, i := range reg.Instances {
+		if i.Template == templateName {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Stale returns the instances of t that were rendered from a different
+// version of t
+func (reg *Registry) Stale(t *Template) []Instance {
+	fingerprint := t.Fingerprint()
+	var result []Instance
+	for _, i := range reg.InstancesOf(t.Name) {
+		if i.Fingerprint != fingerprint {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Apply renders t with values, creates the resulting policy and records it
+// in reg
+func Apply(ctx context.Context, client *bastionzero.Client, t *Template, values Values, inv *Inventory, reg *Registry) (*Instance, error) {
+	policy, err := Render(t, values, inv)
+	if err != nil {
+		return nil, err
+	}
+
+	var created policies.PolicyInterface
+	switch p := policy.(type) {
+	case *policies.TargetConnectPolicy:
+		created, _, err = client.Policies.CreateTargetConnectPolicy(ctx, p)
+	case *policies.KubernetesPolicy:
+		created, _, err = client.Policies.CreateKubernetesPolicy(ctx, p)
+	case *policies.ProxyPolicy:
+		created, _, err = client.Policies.CreateProxyPolicy(ctx, p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy %q: %w", policy.GetName(), err)
+	}
+
+	reg.Instances = append(reg.Instances, Instance{
+		PolicyID:    created.GetID(),
+		PolicyType:  created.GetPolicyType(),
+		PolicyName:  created.GetName(),
+		Template:    t.Name,
+		Fingerprint: t.Fingerprint(),
+		Values:      values,
+		RenderedAt:  time.Now().UTC(),
+	})
+	return &reg.Instances[len(reg.Instances)-1], nil
+}
+
+// Rerender re-renders every stale instance of t with its recorded values and
+// modifies the corresponding policies. Subjects added to the policies outside
+// of the template are preserved. It returns the updated instances; on error,
+// the instances updated so far are returned along with the error.
+func Rerender(ctx context.Context, client *bastionzero.Client, t *Template, inv *Inventory, reg *Registry) ([]Instance, error) {
+	fingerprint := t.Fingerprint()
+	var updated []Instance
+	for idx := range reg.Instances {
+		instance := &reg.Instances[idx]
+		if instance.Template != t.Name || instance.Fingerprint == fingerprint {
+			continue
+		}
+		if instance.PolicyType != t.PolicyType {
+			return updated, fmt.Errorf("policy %s is a %s policy but template %q now renders %s policies", instance.PolicyID, instance.PolicyType, t.Name, t.PolicyType)
+		}
+
+		policy, err := Render(t, instance.Values, inv)
+		if err != nil {
+			return updated, fmt.Errorf("failed to render policy %s: %w", instance.PolicyID, err)
+		}
+
+		switch p := policy.(type) {
+		case *policies.TargetConnectPolicy:
+			p.Subjects = nil
+			clearEmptyDescription(&p.Policy)
+			_, _, err = client.Policies.ModifyTargetConnectPolicy(ctx, instance.PolicyID, p)
+		case *policies.KubernetesPolicy:
+			p.Subjects = nil
+			clearEmptyDescription(&p.Policy)
+			_, _, err = client.Policies.ModifyKubernetesPolicy(ctx, instance.PolicyID, p)
+		case *policies.ProxyPolicy:
+			p.Subjects = nil
+			clearEmptyDescription(&p.Policy)
+			_, _, err = client.Policies.ModifyProxyPolicy(ctx, instance.PolicyID, p)
+		}
+		if err != nil {
+			return updated, fmt.Errorf("failed to modify policy %s: %w", instance.PolicyID, err)
+		}
+
+		instance.PolicyName = policy.GetName()
+		instance.Fingerprint = fingerprint
+		instance.RenderedAt = time.Now().UTC()
+		updated = append(updated, *instance)
+	}
+	return updated, nil
+}
+
+// clearEmptyDescription sets an explicit empty description when the template
+// rendered none. Render leaves it nil, which Modify treats as unchanged.
+func clearEmptyDescription(p *policies.Policy) {
+	if p.Description == nil {
+		empty := ""
+		p.Description = &empty
+	}
+}
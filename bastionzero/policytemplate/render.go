@@ -0,0 +1,142 @@
+package policytemplate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/verbtype"
+)
+
+// Inventory is the live organization data templates are rendered against
+type Inventory struct {
+	// Groups maps IdP group names to group IDs
+	Groups map[string]string
+	// Environments maps environment names to environment IDs
+	Environments map[string]string
+}
+
+// FetchInventory fetches the groups and environments of the organization the
+// client is authenticated against
+func FetchInventory(ctx context.Context, client *bastionzero.Client) (*Inventory, error) {
+	inv := &Inventory{
+		Groups:       make(map[string]string),
+		Environments: make(map[string]string),
+	}
+
+	groups, _, err := client.Organization.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, g := range groups {
+		inv.Groups[g.Name] = g.ID
+	}
+
+	envs, _, err := client.Environments.ListEnvironments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, e := range envs {
+		inv.Environments[e.Name] = e.ID
+	}
+
+	return inv, nil
+}
+
+// Render renders t with values into a *TargetConnectPolicy,
+// *KubernetesPolicy or *ProxyPolicy. Group and environment names are resolved
+// to IDs using inv; unknown names are an error.
+func Render(t *Template, values Values, inv *Inventory) (policies.PolicyInterface, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	resolved, err := t.resolveValues(values)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %w", t.Name, err)
+	}
+
+	name, err := executeText("name", t.PolicyName, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: failed to render policy name: %w", t.Name, err)
+	}
+	description, err := executeText("description", t.PolicyDescription, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: failed to render policy description: %w", t.Name, err)
+	}
+
+	groups := []policies.Group{}
+	for _, groupName := range expand(t.Groups, resolved) {
+		id, ok := inv.Groups[groupName]
+		if !ok {
+			return nil, fmt.Errorf("template %q: group %q does not exist", t.Name, groupName)
+		}
+		groups = append(groups, policies.Group{ID: id, Name: groupName})
+	}
+
+	environments := []policies.Environment{}
+	for _, envName := range expand(t.Environments, resolved) {
+		id, ok := inv.Environments[envName]
+		if !ok {
+			return nil, fmt.Errorf("template %q: environment %q does not exist", t.Name, envName)
+		}
+		environments = append(environments, policies.Environment{ID: id})
+	}
+
+	common := policies.Policy{
+		Name:     name,
+		Subjects: &[]policies.Subject{},
+		Groups:   &groups,
+	}
+	if description != "" {
+		common.Description = &description
+	}
+
+	targetUsers := []policies.TargetUser{}
+	for _, u := range expand(t.TargetUsers, resolved) {
+		targetUsers = append(targetUsers, policies.TargetUser{Username: u})
+	}
+
+	switch t.PolicyType {
+	case policytype.TargetConnect:
+		verbs := []policies.Verb{}
+		for _, v := range expand(t.Verbs, resolved) {
+			verb := verbtype.VerbType(v)
+			if !verb.Valid() {
+				return nil, fmt.Errorf("template %q: invalid verb %q", t.Name, v)
+			}
+			verbs = append(verbs, policies.Verb{Type: verb})
+		}
+		return &policies.TargetConnectPolicy{
+			Policy:       common,
+			Environments: &environments,
+			Targets:      &[]policies.Target{},
+			TargetUsers:  &targetUsers,
+			Verbs:        &verbs,
+		}, nil
+	case policytype.Kubernetes:
+		clusterUsers := []policies.ClusterUser{}
+		for _, u := range expand(t.ClusterUsers, resolved) {
+			clusterUsers = append(clusterUsers, policies.ClusterUser{Name: u})
+		}
+		clusterGroups := []policies.ClusterGroup{}
+		for _, g := range expand(t.ClusterGroups, resolved) {
+			clusterGroups = append(clusterGroups, policies.ClusterGroup{Name: g})
+		}
+		return &policies.KubernetesPolicy{
+			Policy:        common,
+			Environments:  &environments,
+			Clusters:      &[]policies.Cluster{},
+			ClusterUsers:  &clusterUsers,
+			ClusterGroups: &clusterGroups,
+		}, nil
+	default:
+		return &policies.ProxyPolicy{
+			Policy:       common,
+			Environments: &environments,
+			Targets:      &[]policies.Target{},
+			TargetUsers:  &targetUsers,
+		}, nil
+	}
+}
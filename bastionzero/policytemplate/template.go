@@ -0,0 +1,262 @@
+// Package policytemplate renders target connect, Kubernetes and proxy policies
+// from named templates with typed parameters.
+//
+// A Template describes a policy in which any field may refer to a parameter.
+// The policy's name and description are Go text/template strings (e.g.
+// "{{.team}}-{{.environment}}-ssh"). List fields such as Environments or
+// TargetUsers hold literal values and parameter references of the form
+// "$name", which expand to the parameter's value.
+//
+// Rendering validates parameter values against live organization data: Group
+// parameters must name an existing IdP group and Environment parameters must
+// name an existing environment. A Registry records the policies created from
+// each template so that they can be re-rendered when the template changes.
+package policytemplate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/policytemplate/paramtype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+)
+
+// Param declares a template parameter
+type Param struct {
+	// Name is used to refer to the parameter as "$name" in list fields and
+	// as {{.name}} in the name and description
+	Name        string              `json:"name"`
+	Type        paramtype.ParamType `json:"type"`
+	Description string              `json:"description,omitempty"`
+	// Default is used when no value is provided. It must be a string for
+	// scalar types and a list of strings for list types. A parameter without
+	// a default is required.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// Template describes a family of near-identical policies
+type Template struct {
+	// Name uniquely identifies the template
+	Name string `json:"name"`
+	// PolicyType is one of TargetConnect, Kubernetes or Proxy
+	PolicyType policytype.PolicyType `json:"policyType"`
+	Params     []Param               `json:"params"`
+
+	// PolicyName and PolicyDescription are text/template strings rendered
+	// with the parameter values
+	PolicyName        string `json:"policyName"`
+	PolicyDescription string `json:"policyDescription,omitempty"`
+
+	// Groups lists IdP group names or references to Group parameters
+	Groups []string `json:"groups,omitempty"`
+	// Environments lists environment names or references to Environment
+	// parameters
+	Environments []string `json:"environments,omitempty"`
+	// TargetUsers is used by target connect and proxy policies
+	TargetUsers []string `json:"targetUsers,omitempty"`
+	// Verbs is used by target connect policies
+	Verbs []string `json:"verbs,omitempty"`
+	// ClusterUsers and ClusterGroups are used by Kubernetes policies
+	ClusterUsers  []string `json:"clusterUsers,omitempty"`
+	ClusterGroups []string `json:"clusterGroups,omitempty"`
+}
+
+// Values holds parameter values by parameter name. Values of scalar
+// parameters are strings; values of list parameters are []string.
+type Values map[string]interface{}
+
+// Validate checks that the template is well formed
+func (t *Template) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	switch t.PolicyType {
+	case policytype.TargetConnect, policytype.Kubernetes, policytype.Proxy:
+	default:
+		return fmt.Errorf("template %q: unsupported policy type %q", t.Name, t.PolicyType)
+	}
+	if t.PolicyName == "" {
+		return fmt.Errorf("template %q: policy name is required", t.Name)
+	}
+
+	params := make(map[string]Param, len(t.Params))
+	for _, p := range t.Params {
+		if p.Name == "" {
+			return fmt.Errorf("template %q: parameter name is required", t.Name)
+		}
+		if _, ok := params[p.Name]; ok {
+			return fmt.Errorf("template %q: duplicate parameter %q", t.Name, p.Name)
+		}
+		if !p.Type.Valid() {
+			return fmt.Errorf("template %q: parameter %q has invalid type %q", t.Name, p.Name, p.Type)
+		}
+		if p.Default != nil {
+			if _, err := normalizeValue(p, p.Default); err != nil {
+				return fmt.Errorf("template %q: default of %w", t.Name, err)
+			}
+		}
+		params[p.Name] = p
+	}
+
+	if _, err := template.New("name").Option("missingkey=error").Parse(t.PolicyName); err != nil {
+		return fmt.Errorf("template %q: invalid policy name: %w", t.Name, err)
+	}
+	if _, err := template.New("description").Option("missingkey=error").Parse(t.PolicyDescription); err != nil {
+		return fmt.Errorf("template %q: invalid policy description: %w", t.Name, err)
+	}
+
+	fields := []struct {
+		name    string
+		entries []string
+		allowed []paramtype.ParamType
+	}{
+		{"groups", t.Groups, []paramtype.ParamType{paramtype.Group}},
+		{"environments", t.Environments, []paramtype.ParamType{paramtype.Environment}},
+		{"targetUsers", t.TargetUsers, []paramtype.ParamType{paramtype.String, paramtype.StringList}},
+		{"verbs", t.Verbs, []paramtype.ParamType{paramtype.Verbs}},
+		{"clusterUsers", t.ClusterUsers, []paramtype.ParamType{paramtype.String, paramtype.StringList}},
+		{"clusterGroups", t.ClusterGroups, []paramtype.ParamType{paramtype.String, paramtype.StringList}},
+	}
+	for _, f := range fields {
+		for _, entry := range f.entries {
+			name, isRef := paramRef(entry)
+			if !isRef {
+				continue
+			}
+			p, ok := params[name]
+			if !ok {
+				return fmt.Errorf("template %q: %s refers to unknown parameter %q", t.Name, f.name, name)
+			}
+			if !containsType(f.allowed, p.Type) {
+				return fmt.Errorf("template %q: %s cannot refer to parameter %q of type %s", t.Name, f.name, name, p.Type)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Fingerprint returns a hash of the template's definition. It changes
+// whenever the template changes and is used to find stale policies.
+func (t *Template) Fingerprint() string {
+	data, _ := json.Marshal(t)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveValues applies defaults and checks the types of values. The result
+// maps parameter names to string or []string values.
+func (t *Template) resolveValues(values Values) (map[string]interface{}, error) {
+	known := make(map[string]struct{}, len(t.Params))
+	resolved := make(map[string]interface{}, len(t.Params))
+	for _, p := range t.Params {
+		known[p.Name] = struct{}{}
+		v, ok := values[p.Name]
+		if !ok {
+			if p.Default == nil {
+				return nil, fmt.Errorf("missing value for parameter %q", p.Name)
+			}
+			v = p.Default
+		}
+		nv, err := normalizeValue(p, v)
+		if err != nil {
+			return nil, err
+		}
+		resolved[p.Name] = nv
+	}
+
+	var unknown []string
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", "))
+	}
+
+	return resolved, nil
+}
+
+// normalizeValue converts v to a string or []string according to p's type.
+// Lists decoded from JSON ([]interface{}) are accepted.
+func normalizeValue(p Param, v interface{}) (interface{}, error) {
+	if !p.Type.IsList() {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter %q must be a string", p.Name)
+		}
+		return s, nil
+	}
+
+	switch l := v.(type) {
+	case []string:
+		return l, nil
+	case []interface{}:
+		result := make([]string, len(l))
+		for i, e := range l {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("parameter %q must be a list of strings", p.Name)
+			}
+			result[i] = s
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("parameter %q must be a list of strings", p.Name)
+	}
+}
+
+// expand returns entries with parameter references replaced by their values
+func expand(entries []string, values map[string]interface{}) []string {
+	var result []string
+	for _, entry := range entries {
+		name, isRef := paramRef(entry)
+		if !isRef {
+			result = append(result, entry)
+			continue
+		}
+		switch v := values[name].(type) {
+		case string:
+			result = append(result, v)
+		case []string:
+			result = append(result, v...)
+		}
+	}
+	return result
+}
+
+func executeText(name, text string, values map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func paramRef(entry string) (string, bool) {
+	if strings.HasPrefix(entry, "$") && len(entry) > 1 {
+		return entry[1:], true
+	}
+	return "", false
+}
+
+func containsType(types []paramtype.ParamType, t paramtype.ParamType) bool {
+	for _, e := range types {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+// Package resolve maps human readable names to the IDs required by most
+// BastionZero API methods.
+//
+// A Resolver resolves target references of the form "name" or
+// "name@environment" (where environment is an environment name or ID),
+// environment names, and subject emails. Lookups are served from a short-lived
+// cache. When a name matches more than one object, an *AmbiguousError lists the
+// candidates.
+//
+// To invalidate the cache automatically after mutations made through the same
+// client, wrap the client's transport:
+//
+//	httpClient := &http.Client{}
+//	client, _ := bastionzero.NewFromAPISecret(httpClient, secret)
+//	resolver := resolve.New(client, nil)
+//	httpClient.Transport = resolver.WrapTransport(httpClient.Transport)
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/inventory"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+const defaultTTL = 30 * time.Second
+
+// Candidate is one of several objects matching an ambiguous reference
+type Candidate struct {
+	ID   string
+	Name string
+	// EnvironmentID is set for targets
+	EnvironmentID string
+	// Type is set for targets
+	Type targettype.TargetType
+}
+
+// AmbiguousError is returned when a reference matches more than one object
+type AmbiguousError struct {
+	// Kind is what was being resolved, e.g. "target" or "environment"
+	Kind       string
+	Ref        string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	descriptions := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		if c.Type != "" {
+			descriptions[i] = fmt.Sprintf("%s (%s target %s in environment %s)", c.Name, c.Type, c.ID, c.EnvironmentID)
+		} else {
+			descriptions[i] = fmt.Sprintf("%s (%s)", c.Name, c.ID)
+		}
+	}
+	return fmt.Sprintf("%s %q is ambiguous; candidates: %s", e.Kind, e.Ref, strings.Join(descriptions, ", "))
+}
+
+// NotFoundError is returned when a reference matches nothing
+type NotFoundError struct {
+	Kind string
+	Ref  string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", e.Kind, e.Ref)
+}
+
+// Options specifies the optional parameters to New
+type Options struct {
+	// TTL is how long fetched data is cached. Defaults to 30 seconds.
+	TTL time.Duration
+}
+
+// Resolver resolves names to IDs. It is safe for concurrent use.
+type Resolver struct {
+	client *bastionzero.Client
+	ttl    time.Duration
+
+	mu                  sync.Mutex
+	inv                 *inventory.Inventory
+	invFetchedAt        time.Time
+	environments        map[string][]Candidate // by name
+	environmentIDs      map[string]struct{}
+	environmentsFetchAt time.Time
+	subjects            map[string]subjectEntry // by lower-cased email
+}
+
+type subjectEntry struct {
+	id        string
+	fetchedAt time.Time
+}
+
+// New returns a Resolver that uses client
+func New(client *bastionzero.Client, opts *Options) *Resolver {
+	ttl := defaultTTL
+	if opts != nil && opts.TTL > 0 {
+		ttl = opts.TTL
+	}
+	return &Resolver{
+		client:   client,
+		ttl:      ttl,
+		subjects: make(map[string]subjectEntry),
+	}
+}
+
+// Target resolves a target reference of the form "name" or
+// "name@environment". If types is not empty, only targets of these types are
+// considered.
+func (r *Resolver) Target(ctx context.Context, ref string, types ...targettype.TargetType) (targets.TargetInterface, error) {
+	name, env := splitRef(ref)
+
+	var envIDs map[string]struct{}
+	if env != "" {
+		var err error
+		if envIDs, err = r.environmentMatches(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+
+	inv, err := r.inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []targets.TargetInterface
+	for _, t := range inv.ByName(name) {
+		if len(types) > 0 && !containsType(types, t.GetTargetType()) {
+			continue
+		}
+		if envIDs != nil {
+			if _, ok := envIDs[t.GetEnvironmentID()]; !ok {
+				continue
+			}
+		}
+		matches = append(matches, t)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, &NotFoundError{Kind: "target", Ref: ref}
+	case 1:
+		return matches[0], nil
+	default:
+		candidates := make([]Candidate, len(matches))
+		for i, t := range matches {
+			candidates[i] = Candidate{ID: t.GetID(), Name: t.GetName(), EnvironmentID: t.GetEnvironmentID(), Type: t.GetTargetType()}
+		}
+		return nil, &AmbiguousError{Kind: "target", Ref: ref, Candidates: candidates}
+	}
+}
+
+// TargetID resolves a target reference to the target's ID. See Target.
+func (r *Resolver) TargetID(ctx context.Context, ref string, types ...targettype.TargetType) (string, error) {
+	t, err := r.Target(ctx, ref, types...)
+	if err != nil {
+		return "", err
+	}
+	return t.GetID(), nil
+}
+
+// EnvironmentID resolves an environment name to its ID
+func (r *Resolver) EnvironmentID(ctx context.Context, name string) (string, error) {
+	if err := r.loadEnvironments(ctx); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	candidates := r.environments[name]
+	r.mu.Unlock()
+
+	switch len(candidates) {
+	case 0:
+		return "", &NotFoundError{Kind: "environment", Ref: name}
+	case 1:
+		return candidates[0].ID, nil
+	default:
+		return "", &AmbiguousError{Kind: "environment", Ref: name, Candidates: candidates}
+	}
+}
+
+// SubjectID resolves a subject's email to its ID
+func (r *Resolver) SubjectID(ctx context.Context, email string) (string, error) {
+	key := strings.ToLower(email)
+
+	r.mu.Lock()
+	entry, ok := r.subjects[key]
+	r.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < r.ttl {
+		return entry.id, nil
+	}
+
+	subject, _, err := r.client.Subjects.GetSubject(ctx, email)
+	if err != nil {
+		if apierror.IsAPIErrorStatusCode(err, http.StatusNotFound) {
+			return "", &NotFoundError{Kind: "subject", Ref: email}
+		}
+		return "", fmt.Errorf("failed to get subject %s: %w", email, err)
+	}
+
+	r.mu.Lock()
+	r.subjects[key] = subjectEntry{id: subject.ID, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return subject.ID, nil
+}
+
+// Invalidate clears the whole cache
+func (r *Resolver) Invalidate() {
+	r.InvalidateTargets()
+	r.InvalidateEnvironments()
+	r.InvalidateSubjects()
+}
+
+// InvalidateTargets clears the cached targets
+func (r *Resolver) InvalidateTargets() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inv = nil
+}
+
+// InvalidateEnvironments clears the cached environments
+func (r *Resolver) InvalidateEnvironments() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.environments = nil
+	r.environmentIDs = nil
+}
+
+// InvalidateSubjects clears the cached subjects
+func (r *Resolver) InvalidateSubjects() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subjects = make(map[string]subjectEntry)
+}
+
+func (r *Resolver) inventory(ctx context.Context) (*inventory.Inventory, error) {
+	r.mu.Lock()
+	if r.inv != nil && time.Since(r.invFetchedAt) < r.ttl {
+		inv := r.inv
+		r.mu.Unlock()
+		return inv, nil
+	}
+	r.mu.Unlock()
+
+	inv, err := inventory.Fetch(ctx, r.client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.inv = inv
+	r.invFetchedAt = time.Now()
+	r.mu.Unlock()
+	return inv, nil
+}
+
+func (r *Resolver) loadEnvironments(ctx context.Context) error {
+	r.mu.Lock()
+	fresh := r.environments != nil && time.Since(r.environmentsFetchAt) < r.ttl
+	r.mu.Unlock()
+	if fresh {
+		return nil
+	}
+
+	envs, _, err := r.client.Environments.ListEnvironments(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	byName := make(map[string][]Candidate)
+	ids := make(map[string]struct{}, len(envs))
+	for _, e := range envs {
+		byName[e.Name] = append(byName[e.Name], Candidate{ID: e.ID, Name: e.Name})
+		ids[e.ID] = struct{}{}
+	}
+
+	r.mu.Lock()
+	r.environments = byName
+	r.environmentIDs = ids
+	r.environmentsFetchAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// environmentMatches returns the IDs of the environments whose name or ID is
+// env
+func (r *Resolver) environmentMatches(ctx context.Context, env string) (map[string]struct{}, error) {
+	if err := r.loadEnvironments(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]struct{})
+	for _, c := range r.environments[env] {
+		result[c.ID] = struct{}{}
+	}
+	if _, ok := r.environmentIDs[env]; ok {
+		result[env] = struct{}{}
+	}
+	if len(result) == 0 {
+		return nil, &NotFoundError{Kind: "environment", Ref: env}
+	}
+	return result, nil
+}
+
+// splitRef splits "name@environment" into its parts
+func splitRef(ref string) (name string, env string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+func containsType(types []targettype.TargetType, t targettype.TargetType) bool {
+	for _, e := range types {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package resolve
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WrapTransport returns a RoundTripper that sends requests with base (or
+// http.DefaultTransport if nil) and invalidates the affected parts of the
+// cache after every successful mutation (any method other than GET, HEAD or
+// OPTIONS).
+func (r *Resolver) WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &invalidatingTransport{base: base, resolver: r}
+}
+
+type invalidatingTransport struct {
+	base     http.RoundTripper
+	resolver *Resolver
+}
+
+func (t *invalidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return resp, err
+	}
+
+	path := req.URL.Path
+	switch {
+	case strings.Contains(path, "/api/v2/targets"):
+		t.resolver.InvalidateTargets()
+	case strings.Contains(path, "/api/v2/environments"):
+		// Deleting an environment deletes its targets
+		t.resolver.InvalidateEnvironments()
+		t.resolver.InvalidateTargets()
+	case strings.Contains(path, "/api/v2/subjects"),
+		strings.Contains(path, "/api/v2/users"),
+		strings.Contains(path, "/api/v2/service-accounts"),
+		strings.Contains(path, "/api/v2/api-keys"):
+		t.resolver.InvalidateSubjects()
+	}
+	return resp, err
+}
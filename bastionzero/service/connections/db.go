@@ -2,6 +2,7 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
@@ -71,6 +72,25 @@ func (s *ConnectionsService) ListDbConnections(ctx context.Context, opts *ListCo
 	return *connList, resp, nil
 }
 
+// GetDbConnection fetches the specified db connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/db/-id-
+func (s *ConnectionsService) GetDbConnection(ctx context.Context, connectionID string) (*DbConnection, *http.Response, error) {
+	u := fmt.Sprintf(dbSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(DbConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
 // Ensure DbConnection implementation satisfies the expected interfaces.
 var (
 	// DbConnection implements ConnectionInterface
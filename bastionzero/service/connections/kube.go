@@ -2,6 +2,7 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
@@ -71,6 +72,25 @@ func (s *ConnectionsService) ListKubeConnections(ctx context.Context, opts *List
 	return *connList, resp, nil
 }
 
+// GetKubeConnection fetches the specified kube connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/kube/-id-
+func (s *ConnectionsService) GetKubeConnection(ctx context.Context, connectionID string) (*KubeConnection, *http.Response, error) {
+	u := fmt.Sprintf(kubeSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(KubeConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
 // Ensure KubeConnection implementation satisfies the expected interfaces.
 var (
 	// KubeConnection implements ConnectionInterface
@@ -2,6 +2,7 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
@@ -45,6 +46,25 @@ func (s *ConnectionsService) ListRDPConnections(ctx context.Context, opts *ListC
 	return *connList, resp, nil
 }
 
+// GetRDPConnection fetches the specified RDP connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/rdp/-id-
+func (s *ConnectionsService) GetRDPConnection(ctx context.Context, connectionID string) (*RDPConnection, *http.Response, error) {
+	u := fmt.Sprintf(rdpSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(RDPConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
 // Ensure RDPConnection implementation satisfies the expected interfaces.
 var (
 	// RDPConnection implements ConnectionInterface
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
 )
 
 const (
@@ -70,6 +71,30 @@ func (s *ConnectionsService) GetShellConnection(ctx context.Context, connectionI
 	return conn, resp, nil
 }
 
+// ListShellConnections lists all shell connections.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/shell
+func (s *ConnectionsService) ListShellConnections(ctx context.Context, opts *ListConnectionOptions) ([]ShellConnection, *http.Response, error) {
+	u := shellBasePath
+	u, err := client.AddOptions(u, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connList := new([]ShellConnection)
+	resp, err := s.Client.Do(req, connList)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return *connList, resp, nil
+}
+
 // Ensure ShellConnection implementation satisfies the expected interfaces.
 var (
 	// ShellConnection implements ConnectionInterface
@@ -2,6 +2,7 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
@@ -45,6 +46,25 @@ func (s *ConnectionsService) ListSQLServerConnections(ctx context.Context, opts
 	return *connList, resp, nil
 }
 
+// GetSQLServerConnection fetches the specified SQL Server connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/sqlserver/-id-
+func (s *ConnectionsService) GetSQLServerConnection(ctx context.Context, connectionID string) (*SQLServerConnection, *http.Response, error) {
+	u := fmt.Sprintf(sqlServerSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(SQLServerConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
 // Ensure SQLServerConnection implementation satisfies the expected interfaces.
 var (
 	// SQLServerConnection implements ConnectionInterface
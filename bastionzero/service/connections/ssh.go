@@ -2,7 +2,10 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+
+	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
 )
 
 const (
@@ -47,3 +50,52 @@ func (s *ConnectionsService) CreateSshConnection(ctx context.Context, request *C
 
 	return createConnResponse, resp, nil
 }
+
+// GetSSHConnection fetches the specified SSH connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/ssh/-id-
+func (s *ConnectionsService) GetSSHConnection(ctx context.Context, connectionID string) (*SSHConnection, *http.Response, error) {
+	u := fmt.Sprintf(sshSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(SSHConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
+// ListSSHConnections lists all SSH connections.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/ssh
+func (s *ConnectionsService) ListSSHConnections(ctx context.Context, opts *ListConnectionOptions) ([]SSHConnection, *http.Response, error) {
+	u := sshBasePath
+	u, err := client.AddOptions(u, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connList := new([]SSHConnection)
+	resp, err := s.Client.Do(req, connList)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return *connList, resp, nil
+}
+
+// Ensure SSHConnection implementation satisfies the expected interfaces.
+var (
+	// SSHConnection implements ConnectionInterface
+	_ ConnectionInterface = &SSHConnection{}
+)
@@ -2,7 +2,10 @@ package connections
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+
+	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
 )
 
 const (
@@ -40,3 +43,52 @@ func (s *ConnectionsService) CreateWebConnection(ctx context.Context, request *C
 
 	return createConnResponse, resp, nil
 }
+
+// GetWebConnection fetches the specified web connection.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/web/-id-
+func (s *ConnectionsService) GetWebConnection(ctx context.Context, connectionID string) (*WebConnection, *http.Response, error) {
+	u := fmt.Sprintf(webSinglePath, connectionID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn := new(WebConnection)
+	resp, err := s.Client.Do(req, conn)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return conn, resp, nil
+}
+
+// ListWebConnections lists all web connections.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/connections/web
+func (s *ConnectionsService) ListWebConnections(ctx context.Context, opts *ListConnectionOptions) ([]WebConnection, *http.Response, error) {
+	u := webBasePath
+	u, err := client.AddOptions(u, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connList := new([]WebConnection)
+	resp, err := s.Client.Do(req, connList)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return *connList, resp, nil
+}
+
+// Ensure WebConnection implementation satisfies the expected interfaces.
+var (
+	// WebConnection implements ConnectionInterface
+	_ ConnectionInterface = &WebConnection{}
+)
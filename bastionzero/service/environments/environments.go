@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
 	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
@@ -155,3 +156,21 @@ func (s *EnvironmentsService) ModifyEnvironment(ctx context.Context, environment
 
 	return resp, nil
 }
+
+// Validate requires a name
+func (r *CreateEnvironmentRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name == "" {
+		ve.Add("name", "name is required")
+	}
+	return ve.Err()
+}
+
+// Validate checks that the name, if set, is not empty
+func (r *ModifyEnvironmentRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name != nil && *r.Name == "" {
+		ve.Add("name", "name cannot be empty")
+	}
+	return ve.Err()
+}
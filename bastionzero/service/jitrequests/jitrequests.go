@@ -0,0 +1,223 @@
+package jitrequests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/jitrequests/jitrequeststatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies/policytype"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+	"github.com/bastionzero/bastionzero-sdk-go/internal/client"
+)
+
+const (
+	jitRequestsBasePath   = "api/v2/jit-requests"
+	jitRequestsSinglePath = jitRequestsBasePath + "/%s"
+)
+
+// JITRequestsService handles communication with the just in time (JIT) access
+// request endpoints of the BastionZero API.
+//
+// A JIT request asks for temporary access to a target under a JIT policy.
+// Unless the JIT policy is automatically approved, the request stays Pending
+// until it is approved or denied by an admin or by an authorized GitHub action
+// (see GitHubActionsService). Once approved, BastionZero creates the policies
+// granting access, which expire after the JIT policy's duration.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#tag--JIT-Requests
+type JITRequestsService client.Service
+
+// CreateJITRequestRequest is used to request just in time access to a target
+type CreateJITRequestRequest struct {
+	// TargetID is the ID of the target to request access to
+	TargetID string `json:"targetId"`
+	// TargetType is the type of the target
+	TargetType targettype.TargetType `json:"targetType"`
+	// TargetUser is the target user (e.g. Unix username or database user) to
+	// request access as. Optional if the JIT policy allows a single target
+	// user.
+	TargetUser string `json:"targetUser,omitempty"`
+	// Reason is shown to reviewers
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReviewJITRequestRequest is used to approve or deny a JIT request
+type ReviewJITRequestRequest struct {
+	// Reason is recorded with the decision and shown to the requester
+	Reason string `json:"reason,omitempty"`
+}
+
+// CreatedPolicy refers to a policy that was created when a JIT request was
+// approved
+type CreatedPolicy struct {
+	ID string `json:"id"`
+	// Type is one of TargetConnect, Kubernetes or Proxy
+	Type policytype.PolicyType `json:"type"`
+	Name string                `json:"name"`
+}
+
+// JITRequest is a request for just in time access to a target
+type JITRequest struct {
+	ID string `json:"id"`
+	// SubjectID is the ID of the user or service account that made the request
+	SubjectID string `json:"subjectId"`
+	// SubjectEmail is the email of the user or service account that made the
+	// request
+	SubjectEmail string                `json:"subjectEmail"`
+	TargetID     string                `json:"targetId"`
+	TargetType   targettype.TargetType `json:"targetType"`
+	TargetName   string                `json:"targetName"`
+	TargetUser   string                `json:"targetUser"`
+	Reason       string                `json:"reason"`
+	// JITPolicyID is the ID of the JIT policy the request was made under
+	JITPolicyID string `json:"jitPolicyId"`
+	// Status is the status of the request
+	Status      jitrequeststatus.JITRequestStatus `json:"status"`
+	TimeCreated types.Timestamp                   `json:"timeCreated"`
+	// TimeReviewed is the time the request was approved or denied. Nil while
+	// the request is pending or if it was automatically approved.
+	TimeReviewed *types.Timestamp `json:"timeReviewed"`
+	// ReviewedBy is the email of the admin or the ID of the authorized GitHub
+	// action that reviewed the request
+	ReviewedBy   string `json:"reviewedBy"`
+	ReviewReason string `json:"reviewReason"`
+	// TimeExpires is the time the granted access expires. Only set once the
+	// request is approved.
+	TimeExpires *types.Timestamp `json:"timeExpires"`
+	// CreatedPolicies lists the policies that were created when the request
+	// was approved
+	CreatedPolicies []CreatedPolicy `json:"createdPolicies"`
+}
+
+// ListJITRequestsOptions specifies the optional parameters when querying for a
+// list of JIT requests
+type ListJITRequestsOptions struct {
+	// Status filters the list of requests to those with this status
+	Status jitrequeststatus.JITRequestStatus `url:"status,omitempty"`
+	// SubjectID filters the list of requests to those made by this subject
+	SubjectID string `url:"subjectId,omitempty"`
+	// TargetID filters the list of requests to those for this target
+	TargetID string `url:"targetId,omitempty"`
+}
+
+// ListJITRequests lists JIT requests. Admins see every request in the
+// organization; other subjects only see their own requests.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/jit-requests
+func (s *JITRequestsService) ListJITRequests(ctx context.Context, opts *ListJITRequestsOptions) ([]JITRequest, *http.Response, error) {
+	u := jitRequestsBasePath
+	u, err := client.AddOptions(u, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requestList := new([]JITRequest)
+	resp, err := s.Client.Do(req, requestList)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return *requestList, resp, nil
+}
+
+// ListPendingJITRequests lists JIT requests that are waiting for review.
+func (s *JITRequestsService) ListPendingJITRequests(ctx context.Context) ([]JITRequest, *http.Response, error) {
+	return s.ListJITRequests(ctx, &ListJITRequestsOptions{Status: jitrequeststatus.Pending})
+}
+
+// CreateJITRequest requests just in time access to a target. If the JIT
+// policy that applies is automatically approved, the returned request is
+// already Approved.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/jit-requests
+func (s *JITRequestsService) CreateJITRequest(ctx context.Context, request *CreateJITRequestRequest) (*JITRequest, *http.Response, error) {
+	u := jitRequestsBasePath
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jitRequest := new(JITRequest)
+	resp, err := s.Client.Do(req, jitRequest)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return jitRequest, resp, nil
+}
+
+// GetJITRequest fetches the specified JIT request.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#get-/api/v2/jit-requests/-id-
+func (s *JITRequestsService) GetJITRequest(ctx context.Context, requestID string) (*JITRequest, *http.Response, error) {
+	u := fmt.Sprintf(jitRequestsSinglePath, requestID)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jitRequest := new(JITRequest)
+	resp, err := s.Client.Do(req, jitRequest)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return jitRequest, resp, nil
+}
+
+// ApproveJITRequest approves the specified pending JIT request and creates the
+// policies that grant access. Requires an admin or an authorized GitHub
+// action.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/jit-requests/-id-/approve
+func (s *JITRequestsService) ApproveJITRequest(ctx context.Context, requestID string, request *ReviewJITRequestRequest) (*JITRequest, *http.Response, error) {
+	return s.review(ctx, requestID, "approve", request)
+}
+
+// DenyJITRequest denies the specified pending JIT request. Requires an admin
+// or an authorized GitHub action.
+//
+// BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/jit-requests/-id-/deny
+func (s *JITRequestsService) DenyJITRequest(ctx context.Context, requestID string, request *ReviewJITRequestRequest) (*JITRequest, *http.Response, error) {
+	return s.review(ctx, requestID, "deny", request)
+}
+
+func (s *JITRequestsService) review(ctx context.Context, requestID string, action string, request *ReviewJITRequestRequest) (*JITRequest, *http.Response, error) {
+	if request == nil {
+		request = new(ReviewJITRequestRequest)
+	}
+
+	u := fmt.Sprintf(jitRequestsSinglePath, requestID) + "/" + action
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jitRequest := new(JITRequest)
+	resp, err := s.Client.Do(req, jitRequest)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return jitRequest, resp, nil
+}
+
+// Validate requires a target ID and a valid target type
+func (r *CreateJITRequestRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetID == "" {
+		ve.Add("targetId", "targetId is required")
+	}
+	if !r.TargetType.Valid() {
+		ve.Add("targetType", "%q is not a valid target type", r.TargetType)
+	}
+	return ve.Err()
+}
@@ -0,0 +1,18 @@
+package jitrequeststatus
+
+//go:generate go run github.com/lindell/string-enumer -t JITRequestStatus -o ./generated.go .
+
+// JITRequestStatus represents the status of a just in time access request
+type JITRequestStatus string
+
+const (
+	// Pending denotes that the request is waiting for a reviewer
+	Pending JITRequestStatus = "Pending"
+	// Approved denotes that the request was approved and access was granted
+	Approved JITRequestStatus = "Approved"
+	// Denied denotes that the request was denied by a reviewer
+	Denied JITRequestStatus = "Denied"
+	// Expired denotes that the request was not reviewed in time or that the
+	// access it granted has expired
+	Expired JITRequestStatus = "Expired"
+)
@@ -0,0 +1,122 @@
+package jitrequests
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/jitrequests/jitrequeststatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// WaitForAccessOptions specifies the optional parameters to WaitForAccess
+type WaitForAccessOptions struct {
+	// PollInterval is the time between status checks. Defaults to 5 seconds.
+	PollInterval time.Duration
+}
+
+// Access describes access granted by an approved JIT request
+type Access struct {
+	// Request is the approved JIT request
+	Request *JITRequest
+	// AccessExpirationTime is the time the access expires, as reported by the
+	// target's AccessDetails when listing targets
+	AccessExpirationTime *types.Timestamp
+}
+
+// WaitForAccess polls the specified JIT request until it is approved and the
+// granted access is visible on the target. It returns an error if the request
+// is denied or expires, or when ctx is done.
+//
+// WaitForAccess must be called by the subject that made the request since the
+// expiration time is read from the targets accessible to the caller.
+func (s *JITRequestsService) WaitForAccess(ctx context.Context, requestID string, opts *WaitForAccessOptions) (*Access, error) {
+	interval := defaultPollInterval
+	if opts != nil && opts.PollInterval > 0 {
+		interval = opts.PollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		access, err := s.checkAccess(ctx, requestID)
+		if err != nil || access != nil {
+			return access, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAccess returns nil, nil if access has not been granted yet
+func (s *JITRequestsService) checkAccess(ctx context.Context, requestID string) (*Access, error) {
+	jitRequest, _, err := s.GetJITRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch jitRequest.Status {
+	case jitrequeststatus.Denied, jitrequeststatus.Expired:
+		return nil, fmt.Errorf("JIT request %s was %s", requestID, jitRequest.Status)
+	case jitrequeststatus.Approved:
+	default:
+		return nil, nil
+	}
+
+	// The AllTargetsService shares the same underlying client
+	allTargets, _, err := (*targets_disambiguated.AllTargetsService)(s).ListAllTargets(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	details := findAccessDetails(allTargets, jitRequest.TargetID)
+	if details == nil || details.AccessExpirationTime == nil {
+		// The policies created by the approval have not propagated yet
+		return nil, nil
+	}
+
+	return &Access{Request: jitRequest, AccessExpirationTime: details.AccessExpirationTime}, nil
+}
+
+func findAccessDetails(all *targets_disambiguated.AllTargetsResponse, targetID string) *targets_disambiguated.AccessDetails {
+	var targets []*targets_disambiguated.Target
+	for i := range all.Db {
+		targets = append(targets, &all.Db[i].Target)
+	}
+	for i := range all.Kubernetes {
+		targets = append(targets, &all.Kubernetes[i].Target)
+	}
+	for i := range all.FileTransfer {
+		targets = append(targets, &all.FileTransfer[i].Target)
+	}
+	for i := range all.Rdp {
+		targets = append(targets, &all.Rdp[i].Target)
+	}
+	for i := range all.Shell {
+		targets = append(targets, &all.Shell[i].Target)
+	}
+	for i := range all.Ssh {
+		targets = append(targets, &all.Ssh[i].Target)
+	}
+	for i := range all.SqlServer {
+		targets = append(targets, &all.SqlServer[i].Target)
+	}
+	for i := range all.Web {
+		targets = append(targets, &all.Web[i].Target)
+	}
+
+	for _, t := range targets {
+		if t.ID == targetID && t.AccessDetails != nil && t.AccessDetails.JIT {
+			return t.AccessDetails
+		}
+	}
+	return nil
+}
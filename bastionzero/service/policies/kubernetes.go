@@ -73,7 +73,7 @@ func (s *PoliciesService) ListKubernetesPolicies(ctx context.Context, opts *List
 // BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/policies/kubernetes
 func (s *PoliciesService) CreateKubernetesPolicy(ctx context.Context, policy *KubernetesPolicy) (*KubernetesPolicy, *http.Response, error) {
 	u := kubernetesBasePath
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, policy)
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, &createRequest{policy: policy})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,7 +51,7 @@ func (s *PoliciesService) ListOrganizationControlsPolicies(ctx context.Context,
 // BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/policies/organization-controls
 func (s *PoliciesService) CreateOrganizationControlsPolicy(ctx context.Context, policy *OrganizationControlsPolicy) (*OrganizationControlsPolicy, *http.Response, error) {
 	u := orgControlsBasePath
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, policy)
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, &createRequest{policy: policy})
 	if err != nil {
 		return nil, nil, err
 	}
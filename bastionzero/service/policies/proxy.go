@@ -54,7 +54,7 @@ func (s *PoliciesService) ListProxyPolicies(ctx context.Context, opts *ListPolic
 // BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/policies/proxy
 func (s *PoliciesService) CreateProxyPolicy(ctx context.Context, policy *ProxyPolicy) (*ProxyPolicy, *http.Response, error) {
 	u := proxyBasePath
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, policy)
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, &createRequest{policy: policy})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -52,7 +52,7 @@ func (s *PoliciesService) ListSessionRecordingPolicies(ctx context.Context, opts
 // BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/policies/session-recording
 func (s *PoliciesService) CreateSessionRecordingPolicy(ctx context.Context, policy *SessionRecordingPolicy) (*SessionRecordingPolicy, *http.Response, error) {
 	u := sessionRecordingBasePath
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, policy)
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, &createRequest{policy: policy})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -61,7 +61,7 @@ func (s *PoliciesService) ListTargetConnectPolicies(ctx context.Context, opts *L
 // BastionZero API docs: https://cloud.bastionzero.com/api/#post-/api/v2/policies/target-connect
 func (s *PoliciesService) CreateTargetConnectPolicy(ctx context.Context, policy *TargetConnectPolicy) (*TargetConnectPolicy, *http.Response, error) {
 	u := targetConnectBasePath
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, policy)
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, u, &createRequest{policy: policy})
 	if err != nil {
 		return nil, nil, err
 	}
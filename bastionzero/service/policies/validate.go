@@ -0,0 +1,247 @@
+package policies
+
+import (
+	"encoding/json"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// The Validate methods of policy types check the fields that are set. Since
+// the same types are used to create and to modify policies, a missing name is
+// not reported. The Create methods wrap the policy in a createRequest, so that
+// WithRequestValidation also requires a name when creating a policy.
+
+// Validate checks the IDs and types of the subjects and groups that are set
+func (p *Policy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+// createRequest is the body of a request creating a policy. It is encoded as
+// the policy itself, and its Validate requires a name in addition to the
+// checks of the policy's Validate.
+type createRequest struct {
+	policy interface {
+		GetName() string
+		validate(ve *apierror.ValidationError)
+	}
+}
+
+func (r *createRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.policy)
+}
+
+func (r *createRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.policy.GetName() == "" {
+		ve.Add("name", "name is required")
+	}
+	r.policy.validate(ve)
+	return ve.Err()
+}
+
+func (p *Policy) validate(ve *apierror.ValidationError) {
+	if p.Subjects != nil {
+		for _, s := range *p.Subjects {
+			if s.ID == "" {
+				ve.Add("subjects", "subject ID is required")
+			}
+			if !s.Type.Valid() {
+				ve.Add("subjects", "%q is not a valid subject type", s.Type)
+			}
+		}
+	}
+	if p.Groups != nil {
+		for _, g := range *p.Groups {
+			if g.ID == "" {
+				ve.Add("groups", "group ID is required")
+			}
+		}
+	}
+}
+
+// Validate checks the subjects, groups, environments, targets, target users
+// and verbs that are set. Targets must be Bzero targets or DACs.
+func (p *TargetConnectPolicy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+func (p *TargetConnectPolicy) validate(ve *apierror.ValidationError) {
+	p.Policy.validate(ve)
+	validateEnvironments(ve, p.Environments)
+	validateTargets(ve, p.Targets, targettype.Bzero, targettype.DynamicAccessConfig)
+	validateTargetUsers(ve, p.TargetUsers)
+	if p.Verbs != nil {
+		for _, v := range *p.Verbs {
+			if !v.Type.Valid() {
+				ve.Add("verbs", "%q is not a valid verb type", v.Type)
+			}
+		}
+	}
+}
+
+// Validate checks the subjects, groups, environments and clusters that are set
+// and that no cluster user or cluster group name is empty
+func (p *KubernetesPolicy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+func (p *KubernetesPolicy) validate(ve *apierror.ValidationError) {
+	p.Policy.validate(ve)
+	validateEnvironments(ve, p.Environments)
+	if p.Clusters != nil {
+		for _, c := range *p.Clusters {
+			if c.ID == "" {
+				ve.Add("clusters", "cluster ID is required")
+			}
+		}
+	}
+	if p.ClusterUsers != nil {
+		for _, u := range *p.ClusterUsers {
+			if u.Name == "" {
+				ve.Add("clusterUsers", "cluster user name cannot be empty")
+			}
+		}
+	}
+	if p.ClusterGroups != nil {
+		for _, g := range *p.ClusterGroups {
+			if g.Name == "" {
+				ve.Add("clusterGroups", "cluster group name cannot be empty")
+			}
+		}
+	}
+}
+
+// Validate checks the subjects, groups, environments, targets and target
+// users that are set. Targets must be Db or Web targets.
+func (p *ProxyPolicy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+func (p *ProxyPolicy) validate(ve *apierror.ValidationError) {
+	p.Policy.validate(ve)
+	validateEnvironments(ve, p.Environments)
+	validateTargets(ve, p.Targets, targettype.Db, targettype.Web)
+	validateTargetUsers(ve, p.TargetUsers)
+}
+
+// Validate checks the subjects and groups that are set and that MFADuration
+// is not negative
+func (p *OrganizationControlsPolicy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+func (p *OrganizationControlsPolicy) validate(ve *apierror.ValidationError) {
+	p.Policy.validate(ve)
+	if p.MFADuration != nil && *p.MFADuration < 0 {
+		ve.Add("mfaDuration", "mfaDuration cannot be negative")
+	}
+}
+
+// Validate checks the IDs and types of the subjects and groups that are set
+func (p *SessionRecordingPolicy) Validate() error {
+	ve := &apierror.ValidationError{}
+	p.validate(ve)
+	return ve.Err()
+}
+
+func (p *SessionRecordingPolicy) validate(ve *apierror.ValidationError) {
+	p.Policy.validate(ve)
+}
+
+// Validate requires a name, at least one child policy and a positive
+// duration, and checks the subjects and groups
+func (r *CreateJITPolicyRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name == "" {
+		ve.Add("name", "name is required")
+	}
+	common := Policy{Subjects: &r.Subjects, Groups: &r.Groups}
+	common.validate(ve)
+	if len(r.ChildPolicies) == 0 {
+		ve.Add("childPolicies", "at least one child policy is required")
+	}
+	for _, id := range r.ChildPolicies {
+		if id == "" {
+			ve.Add("childPolicies", "child policy ID cannot be empty")
+		}
+	}
+	if r.Duration == 0 {
+		ve.Add("duration", "duration must be positive")
+	}
+	return ve.Err()
+}
+
+// Validate checks that the name and child policies, if set, are not empty,
+// that the duration, if set, is positive, and checks the subjects and groups
+func (r *ModifyJITPolicyRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name != nil && *r.Name == "" {
+		ve.Add("name", "name cannot be empty")
+	}
+	common := Policy{Subjects: r.Subjects, Groups: r.Groups}
+	common.validate(ve)
+	if r.ChildPolicies != nil && len(*r.ChildPolicies) == 0 {
+		ve.Add("childPolicies", "at least one child policy is required")
+	}
+	if r.Duration != nil && *r.Duration == 0 {
+		ve.Add("duration", "duration must be positive")
+	}
+	return ve.Err()
+}
+
+func validateEnvironments(ve *apierror.ValidationError, environments *[]Environment) {
+	if environments == nil {
+		return
+	}
+	for _, e := range *environments {
+		if e.ID == "" {
+			ve.Add("environments", "environment ID is required")
+		}
+	}
+}
+
+func validateTargets(ve *apierror.ValidationError, targets *[]Target, allowed ...targettype.TargetType) {
+	if targets == nil {
+		return
+	}
+	for _, t := range *targets {
+		if t.ID == "" {
+			ve.Add("targets", "target ID is required")
+		}
+		if !t.Type.Valid() {
+			ve.Add("targets", "%q is not a valid target type", t.Type)
+			continue
+		}
+		isAllowed := false
+		for _, a := range allowed {
+			if t.Type == a {
+				isAllowed = true
+			}
+		}
+		if !isAllowed {
+			ve.Add("targets", "target type %s is not supported by this policy", t.Type)
+		}
+	}
+}
+
+func validateTargetUsers(ve *apierror.ValidationError, targetUsers *[]TargetUser) {
+	if targetUsers == nil {
+		return
+	}
+	for _, u := range *targetUsers {
+		if u.Username == "" {
+			ve.Add("targetUsers", "target user name cannot be empty")
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
 	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/subjecttype"
@@ -186,3 +187,15 @@ var (
 )
 
 func (s *ServiceAccount) GetSubjectType() subjecttype.SubjectType { return subjecttype.ServiceAccount }
+
+// Validate requires an email and a JWKS URL
+func (r *CreateServiceAccountRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Email == "" {
+		ve.Add("email", "email is required")
+	}
+	if r.JwksURL == "" {
+		ve.Add("jwksUrl", "jwksUrl is required")
+	}
+	return ve.Err()
+}
@@ -0,0 +1,142 @@
+// Package agentconfig describes the Bzero agent configuration keys that can be
+// set with TargetsService.UpdateAgentConfig, along with the type and allowed
+// values of each key.
+//
+// The catalogue is deliberately small: DefaultCatalogue only holds the keys
+// this SDK knows the agent to accept, which is currently just LogLevel. The
+// API does not return an agent's configuration, so none of these keys can be
+// verified after an update. Callers that know of further keys, or of a way to
+// observe their effect, can add them to a copy of DefaultCatalogue.
+package agentconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+)
+
+// ValueType is the type of an agent configuration value. Values are always
+// sent as strings; the type determines which strings are accepted.
+type ValueType string
+
+const (
+	// String accepts any non-empty value
+	String ValueType = "String"
+	// Bool accepts the values accepted by strconv.ParseBool
+	Bool ValueType = "Bool"
+	// Int accepts base 10 integers
+	Int ValueType = "Int"
+	// Duration accepts the values accepted by time.ParseDuration
+	Duration ValueType = "Duration"
+	// Enum accepts one of the key's AllowedValues
+	Enum ValueType = "Enum"
+)
+
+// Key describes an agent configuration key
+type Key struct {
+	// Name is the key as sent in UpdateAgentConfigRequest.Key
+	Name string
+	Type ValueType
+	// AllowedValues lists the accepted values of an Enum key
+	AllowedValues []string
+	Description   string
+	// Verify reports whether target reflects value. It is nil for keys the
+	// agent does not expose through the API, which includes every key in
+	// DefaultCatalogue.
+	Verify func(target *targets.BzeroTarget, value string) bool
+}
+
+// LogLevel is the minimum level of the messages the agent logs
+var LogLevel = Key{
+	Name:          "logLevel",
+	Type:          Enum,
+	AllowedValues: []string{"trace", "debug", "info", "warn", "error"},
+	Description:   "Minimum level of the messages the agent logs",
+}
+
+// Validate returns an error if value is not valid for the key
+func (k Key) Validate(value string) error {
+	var err error
+	switch k.Type {
+	case String:
+		if value == "" {
+			err = fmt.Errorf("value cannot be empty")
+		}
+	case Bool:
+		_, err = strconv.ParseBool(value)
+	case Int:
+		_, err = strconv.Atoi(value)
+	case Duration:
+		_, err = time.ParseDuration(value)
+	case Enum:
+		err = fmt.Errorf("%q is not one of %v", value, k.AllowedValues)
+		for _, v := range k.AllowedValues {
+			if v == value {
+				err = nil
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown value type %s", k.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", k.Name, err)
+	}
+	return nil
+}
+
+// Request returns a request that sets the key to value on the specified
+// target. It returns an error if value is not valid for the key.
+func (k Key) Request(targetID string, value string) (*targets.UpdateAgentConfigRequest, error) {
+	if err := k.Validate(value); err != nil {
+		return nil, err
+	}
+	return &targets.UpdateAgentConfigRequest{TargetID: targetID, Key: k.Name, Value: value}, nil
+}
+
+// Catalogue maps key names to their descriptions
+type Catalogue map[string]Key
+
+// DefaultCatalogue holds the keys known to this SDK. Copy it and add entries
+// to support other keys, e.g. those introduced by newer agents.
+var DefaultCatalogue = Catalogue{
+	LogLevel.Name: LogLevel,
+}
+
+// Lookup returns the key with the given name
+func (c Catalogue) Lookup(name string) (Key, bool) {
+	k, ok := c[name]
+	return k, ok
+}
+
+// Names returns the names of the keys in the catalogue, sorted
+func (c Catalogue) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every key in config is in the catalogue and that its
+// value is valid. The returned error is an *apierror.ValidationError keyed by
+// config key.
+func (c Catalogue) Validate(config map[string]string) error {
+	ve := &apierror.ValidationError{}
+	for name, value := range config {
+		k, ok := c.Lookup(name)
+		if !ok {
+			ve.Add(name, "unknown agent config key %q", name)
+			continue
+		}
+		if err := k.Validate(value); err != nil {
+			ve.Add(name, "%s", err.Error())
+		}
+	}
+	return ve.Err()
+}
@@ -0,0 +1,58 @@
+package dbauthconfig
+
+import (
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+)
+
+var (
+	validAuthenticationTypes   = []string{Default, SplitCert, ServiceAccountInjection}
+	validDatabases             = []string{CockroachDB, MicrosoftSQLServer, MongoDB, MySQL, Postgres}
+	validCloudServiceProviders = []string{AWS, GCP}
+)
+
+// Validate checks that every field that is set holds a value known to this
+// SDK. Use IsSupported to check that the combination of values is supported
+// by BastionZero.
+func (c *DatabaseAuthenticationConfig) Validate() error {
+	ve := &apierror.ValidationError{}
+	if c.AuthenticationType != nil && !contains(validAuthenticationTypes, *c.AuthenticationType) {
+		ve.Add("authenticationType", "%q is not a valid authentication type", *c.AuthenticationType)
+	}
+	if c.Database != nil && !contains(validDatabases, *c.Database) {
+		ve.Add("database", "%q is not a valid database", *c.Database)
+	}
+	if c.CloudServiceProvider != nil && !contains(validCloudServiceProviders, *c.CloudServiceProvider) {
+		ve.Add("cloudServiceProvider", "%q is not a valid cloud service provider", *c.CloudServiceProvider)
+	}
+	return ve.Err()
+}
+
+// IsSupported returns true if c matches one of the supported configurations
+// returned by TargetsService.ListDatabaseAuthenticationConfigs. The Label is
+// not compared.
+func (c *DatabaseAuthenticationConfig) IsSupported(supported []DatabaseAuthenticationConfig) bool {
+	for _, s := range supported {
+		if equal(c.AuthenticationType, s.AuthenticationType) &&
+			equal(c.Database, s.Database) &&
+			equal(c.CloudServiceProvider, s.CloudServiceProvider) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
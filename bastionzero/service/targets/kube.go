@@ -29,6 +29,17 @@ type GenerateActivationTokenAndYamlResponse struct {
 	ActivationToken string `json:"activationToken"`
 }
 
+// String omits the YAML and activation token so that printing the response
+// does not leak the token into logs
+func (r GenerateActivationTokenAndYamlResponse) String() string {
+	return fmt.Sprintf("GenerateActivationTokenAndYamlResponse{YAML: %d bytes, ActivationToken: [REDACTED]}", len(r.YAML))
+}
+
+// GoString is the same as String
+func (r GenerateActivationTokenAndYamlResponse) GoString() string {
+	return r.String()
+}
+
 // ModifyClusterTargetRequest is used to modify a Cluster target
 type ModifyClusterTargetRequest struct {
 	TargetName    *string `json:"name,omitempty"`
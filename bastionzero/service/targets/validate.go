@@ -0,0 +1,236 @@
+package targets
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dbauthconfig"
+)
+
+// Validate requires a name, a remote host and a valid remote port, exactly
+// one of a proxy target or proxy environment, and checks the local port and
+// the fields of the DatabaseAuthenticationConfig
+func (r *CreateDatabaseTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName == "" {
+		ve.Add("targetName", "targetName is required")
+	}
+	validateProxy(ve, r.ProxyTargetID != "", r.ProxyEnvironmentID != "", true)
+	if r.RemoteHost == "" {
+		ve.Add("remoteHost", "remoteHost is required")
+	}
+	validatePort(ve, "remotePort", &r.RemotePort, true)
+	validatePort(ve, "localPort", r.LocalPort, false)
+	if r.DatabaseAuthenticationConfig != nil {
+		ve.Merge("databaseAuthenticationConfig", r.DatabaseAuthenticationConfig.Validate())
+	}
+	return ve.Err()
+}
+
+// ValidateDatabaseAuthenticationConfig checks that the request's
+// DatabaseAuthenticationConfig, if set, is one of the supported
+// configurations returned by ListDatabaseAuthenticationConfigs.
+func (r *CreateDatabaseTargetRequest) ValidateDatabaseAuthenticationConfig(supported []dbauthconfig.DatabaseAuthenticationConfig) error {
+	return validateSupportedConfig(r.DatabaseAuthenticationConfig, supported)
+}
+
+// Validate checks that the name and remote host, if set, are not empty, that
+// the ports are valid, that at most one of a proxy target or proxy environment
+// is set, and the fields of the DatabaseAuthenticationConfig
+func (r *ModifyDatabaseTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName != nil && *r.TargetName == "" {
+		ve.Add("targetName", "targetName cannot be empty")
+	}
+	validateProxy(ve, r.ProxyTargetID != nil && *r.ProxyTargetID != "", r.ProxyEnvironmentID != nil && *r.ProxyEnvironmentID != "", false)
+	if r.RemoteHost != nil && *r.RemoteHost == "" {
+		ve.Add("remoteHost", "remoteHost cannot be empty")
+	}
+	validatePort(ve, "remotePort", r.RemotePort, false)
+	validatePort(ve, "localPort", r.LocalPort, false)
+	if r.DatabaseAuthenticationConfig != nil {
+		ve.Merge("databaseAuthenticationConfig", r.DatabaseAuthenticationConfig.Validate())
+	}
+	return ve.Err()
+}
+
+// ValidateDatabaseAuthenticationConfig checks that the request's
+// DatabaseAuthenticationConfig, if set, is one of the supported
+// configurations returned by ListDatabaseAuthenticationConfigs.
+func (r *ModifyDatabaseTargetRequest) ValidateDatabaseAuthenticationConfig(supported []dbauthconfig.DatabaseAuthenticationConfig) error {
+	return validateSupportedConfig(r.DatabaseAuthenticationConfig, supported)
+}
+
+// Validate requires a name, an http(s) remote URL and a valid remote port,
+// exactly one of a proxy target or proxy environment, and checks the local
+// port
+func (r *CreateWebTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName == "" {
+		ve.Add("targetName", "targetName is required")
+	}
+	validateProxy(ve, r.ProxyTargetID != "", r.ProxyEnvironmentID != "", true)
+	validateWebURL(ve, "remoteHost", r.RemoteHost)
+	validatePort(ve, "remotePort", &r.RemotePort, true)
+	validatePort(ve, "localPort", r.LocalPort, false)
+	return ve.Err()
+}
+
+// Validate checks that the name, if set, is not empty, that the remote URL
+// is http(s) and the ports are valid if set, and that at most one of a proxy
+// target or proxy environment is set
+func (r *ModifyWebTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName != nil && *r.TargetName == "" {
+		ve.Add("targetName", "targetName cannot be empty")
+	}
+	validateProxy(ve, r.ProxyTargetID != nil && *r.ProxyTargetID != "", r.ProxyEnvironmentID != nil && *r.ProxyEnvironmentID != "", false)
+	if r.RemoteHost != nil {
+		validateWebURL(ve, "remoteHost", *r.RemoteHost)
+	}
+	validatePort(ve, "remotePort", r.RemotePort, false)
+	validatePort(ve, "localPort", r.LocalPort, false)
+	return ve.Err()
+}
+
+// Validate checks that the name, if set, is not empty
+func (r *ModifyBzeroTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName != nil && *r.TargetName == "" {
+		ve.Add("targetName", "targetName cannot be empty")
+	}
+	return ve.Err()
+}
+
+// Validate checks that the name, if set, is not empty
+func (r *ModifyClusterTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.TargetName != nil && *r.TargetName == "" {
+		ve.Add("name", "name cannot be empty")
+	}
+	return ve.Err()
+}
+
+// Validate requires a cluster name
+func (r *GenerateActivationTokenAndYamlRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name == "" {
+		ve.Add("name", "name is required")
+	}
+	return ve.Err()
+}
+
+// Validate requires a target ID or a target name
+func (r *RestartBzeroTargetRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	validateTargetSelector(ve, r.TargetID, r.TargetName)
+	return ve.Err()
+}
+
+// Validate requires a target ID or a target name, and an upload logs request
+// ID
+func (r *RequestBzeroAgentLogsRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	validateTargetSelector(ve, r.TargetID, r.TargetName)
+	if r.UploadLogsRequestId == "" {
+		ve.Add("uploadLogsRequestId", "uploadLogsRequestId is required")
+	}
+	return ve.Err()
+}
+
+// Validate requires a target ID or a target name, and a key
+func (r *UpdateAgentConfigRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	validateTargetSelector(ve, r.TargetID, r.TargetName)
+	if r.Key == "" {
+		ve.Add("key", "key is required")
+	}
+	return ve.Err()
+}
+
+// Validate requires a name, an environment ID and http(s) start, stop and
+// health webhooks
+func (r *CreateDynamicAccessConfigurationRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name == "" {
+		ve.Add("name", "name is required")
+	}
+	if r.EnvironmentId == "" {
+		ve.Add("environmentId", "environmentId is required")
+	}
+	validateWebURL(ve, "startWebhook", r.StartWebhook)
+	validateWebURL(ve, "stopWebhook", r.StopWebhook)
+	validateWebURL(ve, "healthWebhook", r.HealthWebhook)
+	return ve.Err()
+}
+
+// Validate checks that the name, if set, is not empty and that the webhooks
+// that are set are http(s) URLs
+func (r *ModifyDynamicAccessConfigurationRequest) Validate() error {
+	ve := &apierror.ValidationError{}
+	if r.Name != nil && *r.Name == "" {
+		ve.Add("name", "name cannot be empty")
+	}
+	if r.StartWebhook != nil {
+		validateWebURL(ve, "startWebhook", *r.StartWebhook)
+	}
+	if r.StopWebhook != nil {
+		validateWebURL(ve, "stopWebhook", *r.StopWebhook)
+	}
+	if r.HealthWebhook != nil {
+		validateWebURL(ve, "healthWebhook", *r.HealthWebhook)
+	}
+	return ve.Err()
+}
+
+// validateProxy checks that at most one (or exactly one if required) of
+// proxyTargetId and proxyEnvironmentId is set
+func validateProxy(ve *apierror.ValidationError, hasTarget, hasEnvironment, required bool) {
+	if hasTarget && hasEnvironment {
+		ve.Add("proxyTargetId", "only one of proxyTargetId or proxyEnvironmentId can be specified")
+	} else if required && !hasTarget && !hasEnvironment {
+		ve.Add("proxyTargetId", "one of proxyTargetId or proxyEnvironmentId must be specified")
+	}
+}
+
+func validatePort(ve *apierror.ValidationError, field string, p *Port, required bool) {
+	if p == nil || p.Value == nil {
+		if required {
+			ve.Add(field, "%s is required", field)
+		}
+		return
+	}
+	if *p.Value < 1 || *p.Value > 65535 {
+		ve.Add(field, "%d is not a valid port", *p.Value)
+	}
+}
+
+func validateWebURL(ve *apierror.ValidationError, field string, value string) {
+	if value == "" {
+		ve.Add(field, "%s is required", field)
+		return
+	}
+	if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+		ve.Add(field, "%s must start with http:// or https://", field)
+		return
+	}
+	if _, err := url.Parse(value); err != nil {
+		ve.Add(field, "%s is not a valid URL", field)
+	}
+}
+
+func validateTargetSelector(ve *apierror.ValidationError, targetID, targetName string) {
+	if targetID == "" && targetName == "" {
+		ve.Add("targetId", "one of targetId or targetName must be specified")
+	}
+}
+
+func validateSupportedConfig(c *dbauthconfig.DatabaseAuthenticationConfig, supported []dbauthconfig.DatabaseAuthenticationConfig) error {
+	if c == nil || c.IsSupported(supported) {
+		return nil
+	}
+	ve := &apierror.ValidationError{}
+	ve.Add("databaseAuthenticationConfig", "configuration is not supported; use one returned by ListDatabaseAuthenticationConfigs")
+	return ve
+}
@@ -0,0 +1,162 @@
+package targets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/apierror"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+const (
+	defaultWaitInitialInterval = 2 * time.Second
+	defaultWaitMaxInterval     = 30 * time.Second
+)
+
+// WaitForTargetStatusOptions specifies the optional parameters to
+// WaitForTargetStatus
+type WaitForTargetStatusOptions struct {
+	// TargetType is the type of the target. If empty, the type is detected by
+	// trying each kind of target in turn.
+	TargetType targettype.TargetType
+	// InitialInterval is the time before the first retry. It doubles after
+	// every check up to MaxInterval. Defaults to 2 seconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the time between checks. Defaults to 30 seconds.
+	MaxInterval time.Duration
+	// Timeout bounds the total time spent waiting. If zero, WaitForTargetStatus
+	// waits until ctx is done.
+	Timeout time.Duration
+}
+
+// errTargetNotFound is returned by getAnyTarget when no kind of target has the
+// given ID
+var errTargetNotFound = errors.New("target not found")
+
+// WaitForTargetStatus polls the specified target with exponential backoff
+// until its status is desired. It returns the target as last fetched.
+//
+// A target that is not found yet (e.g. one that is still registering) and
+// transient errors (network errors and 429 or 5xx responses) are polled again.
+//
+// It returns an error when the timeout elapses, when ctx is done, when
+// fetching the target fails with any other error, or when the target is
+// Terminated and desired is not Terminated. The last fetched target (if any)
+// is returned along with the error.
+func (s *TargetsService) WaitForTargetStatus(ctx context.Context, targetID string, desired targetstatus.TargetStatus, opts *WaitForTargetStatusOptions) (TargetInterface, error) {
+	if opts == nil {
+		opts = &WaitForTargetStatusOptions{}
+	}
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = defaultWaitInitialInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWaitMaxInterval
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	targetType := opts.TargetType
+	var last TargetInterface
+	for {
+		target, err := s.getAnyTarget(ctx, targetID, targetType)
+		switch {
+		case err == nil:
+			last = target
+			targetType = target.GetTargetType()
+
+			status := target.GetStatus()
+			if status == desired {
+				return target, nil
+			}
+			if status == targetstatus.Terminated {
+				return target, fmt.Errorf("target %s was terminated while waiting for status %s", targetID, desired)
+			}
+		case ctx.Err() != nil:
+			return last, waitError(ctx.Err(), targetID, desired, last)
+		case !isRetryableWaitError(err):
+			return last, err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, waitError(ctx.Err(), targetID, desired, last)
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// getAnyTarget gets the target with the given ID. If targetType is empty, each
+// kind of target is tried in turn.
+func (s *TargetsService) getAnyTarget(ctx context.Context, targetID string, targetType targettype.TargetType) (TargetInterface, error) {
+	getters := map[targettype.TargetType]func() (TargetInterface, *http.Response, error){
+		targettype.Bzero: func() (TargetInterface, *http.Response, error) {
+			return s.GetBzeroTarget(ctx, targetID)
+		},
+		targettype.Cluster: func() (TargetInterface, *http.Response, error) {
+			return s.GetClusterTarget(ctx, targetID)
+		},
+		targettype.Db: func() (TargetInterface, *http.Response, error) {
+			return s.GetDatabaseTarget(ctx, targetID)
+		},
+		targettype.Web: func() (TargetInterface, *http.Response, error) {
+			return s.GetWebTarget(ctx, targetID)
+		},
+	}
+
+	if targetType != "" {
+		get, ok := getters[targetType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported target type %s", targetType)
+		}
+		target, _, err := get()
+		return target, err
+	}
+
+	for _, t := range []targettype.TargetType{targettype.Bzero, targettype.Cluster, targettype.Db, targettype.Web} {
+		target, _, err := getters[t]()
+		if err == nil {
+			return target, nil
+		}
+		if !apierror.IsAPIErrorStatusCode(err, http.StatusNotFound) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errTargetNotFound, targetID)
+}
+
+// isRetryableWaitError returns true if WaitForTargetStatus should poll again
+// after err
+func isRetryableWaitError(err error) bool {
+	return errors.Is(err, errTargetNotFound) ||
+		apierror.IsAPIErrorStatusCode(err, http.StatusNotFound) ||
+		apierror.IsTransient(err)
+}
+
+// waitError describes why WaitForTargetStatus stopped waiting when ctx is done
+func waitError(ctxErr error, targetID string, desired targetstatus.TargetStatus, last TargetInterface) error {
+	reason := "cancelled"
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		reason = "timed out"
+	}
+	if last == nil {
+		return fmt.Errorf("%s waiting for target %s to become %s (target not fetched yet): %w", reason, targetID, desired, ctxErr)
+	}
+	return fmt.Errorf("%s waiting for target %s to become %s (last status %s): %w", reason, targetID, desired, last.GetStatus(), ctxErr)
+}
@@ -0,0 +1,214 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/events"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/targetstatus"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+const defaultWatchInterval = 30 * time.Second
+
+// WatchTargetsOptions specifies the optional parameters to WatchTargets
+type WatchTargetsOptions struct {
+	// Interval is the time between polls. Defaults to 30 seconds.
+	Interval time.Duration
+	// Types limits the watch to these types of targets. Defaults to Bzero,
+	// Cluster, Db and Web if empty.
+	Types []targettype.TargetType
+	// TargetIDs limits the watch to these targets. Defaults to all targets if
+	// empty.
+	TargetIDs []string
+	// OnError is called when a poll fails. The watch continues with the next
+	// poll. If nil, errors are ignored.
+	OnError func(error)
+}
+
+// TargetStatusChange describes a change in a target's status observed by
+// WatchTargets
+type TargetStatusChange struct {
+	// Target is the target after the change
+	Target TargetInterface
+	// PreviousStatus is the status the target had at the previous poll. Empty
+	// if the target was not seen before.
+	PreviousStatus targetstatus.TargetStatus
+	// Status is the target's new status
+	Status targetstatus.TargetStatus
+	// Reason is the reason of the most recent agent status change event since
+	// the previous poll. Empty if there is none (e.g. for Db and Web targets,
+	// which have no agent of their own).
+	Reason string
+	// ObservedAt is the time the change was observed
+	ObservedAt time.Time
+}
+
+// WatchTargets polls the targets and emits a TargetStatusChange on the
+// returned channel for every status transition. Targets seen for the first
+// time after the initial poll are emitted with an empty PreviousStatus;
+// targets present at the initial poll are not emitted. The channel is closed
+// when ctx is done.
+//
+// Transitions are enriched with the Reason of the matching
+// AgentStatusChangeEvent from the events service.
+func (s *TargetsService) WatchTargets(ctx context.Context, opts *WatchTargetsOptions) <-chan TargetStatusChange {
+	if opts == nil {
+		opts = &WatchTargetsOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	onError := opts.OnError
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	ch := make(chan TargetStatusChange)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous map[string]targetstatus.TargetStatus
+		lastPoll := time.Now()
+		for {
+			now := time.Now()
+			current, err := s.listWatchedTargets(ctx, opts)
+			if err != nil {
+				onError(err)
+			} else {
+				statuses := make(map[string]targetstatus.TargetStatus, len(current))
+				for _, t := range current {
+					statuses[t.GetID()] = t.GetStatus()
+					if previous == nil {
+						continue
+					}
+					prev, seen := previous[t.GetID()]
+					if seen && prev == t.GetStatus() {
+						continue
+					}
+
+					change := TargetStatusChange{
+						Target:         t,
+						PreviousStatus: prev,
+						Status:         t.GetStatus(),
+						ObservedAt:     now,
+					}
+					if reason, err := s.statusChangeReason(ctx, t, lastPoll); err != nil {
+						onError(err)
+					} else {
+						change.Reason = reason
+					}
+
+					select {
+					case ch <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
+				previous = statuses
+				lastPoll = now
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *TargetsService) listWatchedTargets(ctx context.Context, opts *WatchTargetsOptions) ([]TargetInterface, error) {
+	watchedTypes := opts.Types
+	if len(watchedTypes) == 0 {
+		watchedTypes = []targettype.TargetType{targettype.Bzero, targettype.Cluster, targettype.Db, targettype.Web}
+	}
+	var ids map[string]struct{}
+	if len(opts.TargetIDs) > 0 {
+		ids = make(map[string]struct{}, len(opts.TargetIDs))
+		for _, id := range opts.TargetIDs {
+			ids[id] = struct{}{}
+		}
+	}
+
+	var result []TargetInterface
+	add := func(t TargetInterface) {
+		if ids != nil {
+			if _, ok := ids[t.GetID()]; !ok {
+				return
+			}
+		}
+		result = append(result, t)
+	}
+
+	for _, t := range watchedTypes {
+		switch t {
+		case targettype.Bzero:
+			list, _, err := s.ListBzeroTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Bzero targets: %w", err)
+			}
+			for i := range list {
+				add(&list[i])
+			}
+		case targettype.Cluster:
+			list, _, err := s.ListClusterTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+			}
+			for i := range list {
+				add(&list[i])
+			}
+		case targettype.Db:
+			list, _, err := s.ListDatabaseTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Database targets: %w", err)
+			}
+			for i := range list {
+				add(&list[i])
+			}
+		case targettype.Web:
+			list, _, err := s.ListWebTargets(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list Web targets: %w", err)
+			}
+			for i := range list {
+				add(&list[i])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported target type %s", t)
+		}
+	}
+	return result, nil
+}
+
+// statusChangeReason returns the reason of the most recent agent status change
+// event of t since the given time
+func (s *TargetsService) statusChangeReason(ctx context.Context, t TargetInterface, since time.Time) (string, error) {
+	switch t.GetTargetType() {
+	case targettype.Bzero, targettype.Cluster:
+	default:
+		return "", nil
+	}
+
+	// The EventsService shares the same underlying client
+	statusEvents, _, err := (*events.EventsService)(s).ListAgentStatusChangeEvents(ctx, &events.AgentStatusChangeEventOptions{
+		TargetID:       t.GetID(),
+		StartTimestamp: &types.Timestamp{Time: since},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list agent status change events of target %s: %w", t.GetID(), err)
+	}
+	// Events are returned most recent first
+	if len(statusEvents) == 0 {
+		return "", nil
+	}
+	return statusEvents[0].Reason, nil
+}
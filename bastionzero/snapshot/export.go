@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+)
+
+// Export fetches the configuration of the organization the client is
+// authenticated against. The client must be authenticated as an admin.
+func Export(ctx context.Context, client *bastionzero.Client) (*Snapshot, error) {
+	s := &Snapshot{Version: FormatVersion, CreatedAt: time.Now().UTC()}
+
+	org, _, err := client.Organization.GetOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	s.Organization = *org
+
+	if s.Environments, _, err = client.Environments.ListEnvironments(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	if s.BzeroTargets, _, err = client.Targets.ListBzeroTargets(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Bzero targets: %w", err)
+	}
+	if s.ClusterTargets, _, err = client.Targets.ListClusterTargets(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Cluster targets: %w", err)
+	}
+	if s.DatabaseTargets, _, err = client.Targets.ListDatabaseTargets(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Database targets: %w", err)
+	}
+	if s.WebTargets, _, err = client.Targets.ListWebTargets(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Web targets: %w", err)
+	}
+	if s.DynamicAccessConfigurations, _, err = client.Targets.ListDynamicAccessConfigurations(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list dynamic access configurations: %w", err)
+	}
+
+	if s.Policies.TargetConnect, _, err = client.Policies.ListTargetConnectPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list target connect policies: %w", err)
+	}
+	if s.Policies.Kubernetes, _, err = client.Policies.ListKubernetesPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list Kubernetes policies: %w", err)
+	}
+	if s.Policies.Proxy, _, err = client.Policies.ListProxyPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list proxy policies: %w", err)
+	}
+	if s.Policies.JIT, _, err = client.Policies.ListJITPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list JIT policies: %w", err)
+	}
+	if s.Policies.OrganizationControls, _, err = client.Policies.ListOrganizationControlsPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list organization controls policies: %w", err)
+	}
+	if s.Policies.SessionRecording, _, err = client.Policies.ListSessionRecordingPolicies(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to list session recording policies: %w", err)
+	}
+
+	if s.Subjects, _, err = client.Subjects.ListSubjects(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	if s.Groups, _, err = client.Organization.ListGroups(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	if s.ServiceAccounts, _, err = client.ServiceAccounts.ListServiceAccounts(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	if s.ApiKeys, _, err = client.ApiKeys.ListGlobalApiKeys(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	if s.AuthorizedGitHubActions, _, err = client.GitHubActions.ListAuthorizedGitHubActions(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list authorized GitHub actions: %w", err)
+	}
+	if s.RegistrationKeySettings, _, err = client.Organization.GetRegistrationKeySettings(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get registration key settings: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,842 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/apikeys"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/environments"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/githubactions"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/organization"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/serviceaccounts"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types/targettype"
+)
+
+// Kind is the kind of object restored from a snapshot
+type Kind string
+
+// These constants represent the kinds of objects that are part of a snapshot
+const (
+	EnvironmentKind                Kind = "Environment"
+	BzeroTargetKind                Kind = "BzeroTarget"
+	ClusterTargetKind              Kind = "ClusterTarget"
+	DatabaseTargetKind             Kind = "DatabaseTarget"
+	WebTargetKind                  Kind = "WebTarget"
+	DynamicAccessConfigurationKind Kind = "DynamicAccessConfiguration"
+	TargetConnectPolicyKind        Kind = "TargetConnectPolicy"
+	KubernetesPolicyKind           Kind = "KubernetesPolicy"
+	ProxyPolicyKind                Kind = "ProxyPolicy"
+	JITPolicyKind                  Kind = "JITPolicy"
+	OrganizationControlsPolicyKind Kind = "OrganizationControlsPolicy"
+	SessionRecordingPolicyKind     Kind = "SessionRecordingPolicy"
+	ServiceAccountKind             Kind = "ServiceAccount"
+	ApiKeyKind                     Kind = "ApiKey"
+	AuthorizedGitHubActionKind     Kind = "AuthorizedGitHubAction"
+	RegistrationKeySettingsKind    Kind = "RegistrationKeySettings"
+)
+
+// Status is the outcome of restoring a single object
+type Status string
+
+const (
+	// Created means the object was created in the destination organization
+	Created Status = "Created"
+	// WouldCreate means the object would have been created if the restore
+	// was not a dry run
+	WouldCreate Status = "WouldCreate"
+	// Existing means an object with the same name already exists in the
+	// destination organization and was left unchanged
+	Existing Status = "Existing"
+	// Skipped means the object cannot be recreated through the API
+	Skipped Status = "Skipped"
+	// Failed means the API rejected the request to recreate the object
+	Failed Status = "Failed"
+)
+
+// Item is the outcome of restoring a single object from a snapshot
+type Item struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name"`
+	// SourceID is the ID of the object in the snapshot
+	SourceID string `json:"sourceId"`
+	// DestinationID is the ID of the object in the destination organization.
+	// Empty if the object was skipped, failed or the restore was a dry run.
+	DestinationID string `json:"destinationId,omitempty"`
+	Status        Status `json:"status"`
+	// Notes explain why an object was skipped or failed, or list the parts of
+	// an object that could not be restored (e.g. a secret or a subject that
+	// does not exist in the destination organization)
+	Notes []string `json:"notes,omitempty"`
+}
+
+// RestoreReport describes the outcome of a restore
+type RestoreReport struct {
+	DryRun bool   `json:"dryRun"`
+	Items  []Item `json:"items"`
+}
+
+// Unrestorable returns the items that were skipped or failed, or that were
+// restored with notes requiring manual follow-up
+func (r *RestoreReport) Unrestorable() []Item {
+	var result []Item
+	for _, i := range r.Items {
+		if i.Status == Skipped || i.Status == Failed || len(i.Notes) > 0 {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// WriteJSON writes the report to w as indented JSON
+func (r *RestoreReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// RestoreOptions configures a restore
+type RestoreOptions struct {
+	// DryRun reports what would be restored without creating anything
+	DryRun bool
+	// OnServiceAccountCreated receives the MFA secret of each service account
+	// created by the restore. The server returns the secret only once and the
+	// service account cannot authenticate without it, so service accounts are
+	// skipped if OnServiceAccountCreated is nil. Secrets are never included in
+	// the report.
+	OnServiceAccountCreated func(ServiceAccountSecret)
+}
+
+// ServiceAccountSecret is the MFA secret of a service account created by a
+// restore
+type ServiceAccountSecret struct {
+	Email string
+	// SourceID is the ID of the service account in the snapshot
+	SourceID string
+	// DestinationID is the ID of the new service account
+	DestinationID string
+	MFASecret     string
+}
+
+// String redacts the MFA secret so that it is not logged by accident
+func (s ServiceAccountSecret) String() string {
+	return fmt.Sprintf("ServiceAccountSecret{Email: %s, DestinationID: %s, MFASecret: [redacted]}", s.Email, s.DestinationID)
+}
+
+// GoString redacts the MFA secret so that it is not logged by accident
+func (s ServiceAccountSecret) GoString() string {
+	return s.String()
+}
+
+// Restore recreates the configuration in s in the organization the client is
+// authenticated against. Objects that already exist by name are left
+// unchanged. Restore only returns an error if the destination organization's
+// current state cannot be fetched; failures to recreate individual objects are
+// recorded in the report.
+func Restore(ctx context.Context, client *bastionzero.Client, s *Snapshot, opts *RestoreOptions) (*RestoreReport, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+
+	r := &restorer{
+		ctx:        ctx,
+		client:     client,
+		snap:       s,
+		dryRun:     opts.DryRun,
+		onSecret:   opts.OnServiceAccountCreated,
+		report:     &RestoreReport{DryRun: opts.DryRun, Items: []Item{}},
+		envIDs:     make(map[string]string),
+		targetIDs:  make(map[string]string),
+		policyIDs:  make(map[string]string),
+		subjectIDs: make(map[string]string),
+		groupIDs:   make(map[string]string),
+	}
+
+	dest, err := loadDestination(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	r.dest = dest
+
+	r.restoreEnvironments()
+	r.restoreServiceAccounts()
+	r.mapSubjectsAndGroups()
+	r.restoreTargets()
+	r.restorePolicies()
+	r.restoreApiKeys()
+	r.restoreGitHubActions()
+	r.restoreRegistrationKeySettings()
+
+	return r.report, nil
+}
+
+// destination is the state of the destination organization before restoring
+type destination struct {
+	envsByName map[string]string
+	// targetsByKey maps targetKey() to target ID
+	targetsByKey       map[string]string
+	subjectsByEmail    map[string]string
+	groupsByName       map[string]string
+	policiesByKey      map[string]string
+	apiKeysByName      map[string]apikeys.ApiKey
+	gitHubActions      map[string]string
+	serviceAccountsIDs map[string]string
+}
+
+func targetKey(kind Kind, envID, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, envID, name)
+}
+
+func policyKey(kind Kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+func loadDestination(ctx context.Context, client *bastionzero.Client) (*destination, error) {
+	d := &destination{
+		envsByName:         make(map[string]string),
+		targetsByKey:       make(map[string]string),
+		subjectsByEmail:    make(map[string]string),
+		groupsByName:       make(map[string]string),
+		policiesByKey:      make(map[string]string),
+		apiKeysByName:      make(map[string]apikeys.ApiKey),
+		gitHubActions:      make(map[string]string),
+		serviceAccountsIDs: make(map[string]string),
+	}
+
+	current, err := Export(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch destination organization: %w", err)
+	}
+
+	for _, e := range current.Environments {
+		d.envsByName[e.Name] = e.ID
+	}
+	for _, t := range current.BzeroTargets {
+		d.targetsByKey[targetKey(BzeroTargetKind, t.EnvironmentID, t.Name)] = t.ID
+	}
+	for _, t := range current.ClusterTargets {
+		d.targetsByKey[targetKey(ClusterTargetKind, t.EnvironmentID, t.Name)] = t.ID
+	}
+	for _, t := range current.DatabaseTargets {
+		d.targetsByKey[targetKey(DatabaseTargetKind, t.EnvironmentID, t.Name)] = t.ID
+	}
+	for _, t := range current.WebTargets {
+		d.targetsByKey[targetKey(WebTargetKind, t.EnvironmentID, t.Name)] = t.ID
+	}
+	for _, t := range current.DynamicAccessConfigurations {
+		d.targetsByKey[targetKey(DynamicAccessConfigurationKind, t.EnvironmentId, t.Name)] = t.ID
+	}
+	for _, s := range current.Subjects {
+		d.subjectsByEmail[s.Email] = s.ID
+	}
+	for _, g := range current.Groups {
+		d.groupsByName[g.Name] = g.ID
+	}
+	for _, p := range current.Policies.TargetConnect {
+		d.policiesByKey[policyKey(TargetConnectPolicyKind, p.Name)] = p.ID
+	}
+	for _, p := range current.Policies.Kubernetes {
+		d.policiesByKey[policyKey(KubernetesPolicyKind, p.Name)] = p.ID
+	}
+	for _, p := range current.Policies.Proxy {
+		d.policiesByKey[policyKey(ProxyPolicyKind, p.Name)] = p.ID
+	}
+	for _, p := range current.Policies.JIT {
+		d.policiesByKey[policyKey(JITPolicyKind, p.Name)] = p.ID
+	}
+	for _, p := range current.Policies.OrganizationControls {
+		d.policiesByKey[policyKey(OrganizationControlsPolicyKind, p.Name)] = p.ID
+	}
+	for _, p := range current.Policies.SessionRecording {
+		d.policiesByKey[policyKey(SessionRecordingPolicyKind, p.Name)] = p.ID
+	}
+	for _, k := range current.ApiKeys {
+		d.apiKeysByName[k.Name] = k
+	}
+	for _, a := range current.AuthorizedGitHubActions {
+		d.gitHubActions[a.GitHubActionId] = a.ID
+	}
+	for _, sa := range current.ServiceAccounts {
+		d.serviceAccountsIDs[sa.Email] = sa.ID
+	}
+
+	return d, nil
+}
+
+type restorer struct {
+	ctx    context.Context
+	client *bastionzero.Client
+	snap   *Snapshot
+	dest   *destination
+	dryRun bool
+	report *RestoreReport
+	// onSecret receives the MFA secrets of created service accounts
+	onSecret func(ServiceAccountSecret)
+
+	// Maps from IDs in the snapshot to IDs in the destination organization
+	envIDs     map[string]string
+	targetIDs  map[string]string
+	policyIDs  map[string]string
+	subjectIDs map[string]string
+	groupIDs   map[string]string
+}
+
+// placeholderID is used in place of a destination ID for objects that would be
+// created by a dry run, so that objects referencing them can still be planned
+func placeholderID(sourceID string) string {
+	return "dry-run:" + sourceID
+}
+
+// restore records the outcome of restoring a single object. If existingID is
+// not empty, the object is recorded as Existing. Otherwise create is called
+// (unless this is a dry run) and must return the ID of the new object. If
+// create fails after the object was created, it returns the ID along with the
+// error.
+func (r *restorer) restore(kind Kind, name, sourceID, existingID string, notes []string, create func() (string, error)) string {
+	item := Item{Kind: kind, Name: name, SourceID: sourceID, Notes: notes}
+
+	switch {
+	case existingID != "":
+		item.Status = Existing
+		item.DestinationID = existingID
+		// Notes describe what would have been lost when creating the object,
+		// which does not apply to existing objects
+		item.Notes = nil
+	case r.dryRun:
+		item.Status = WouldCreate
+	default:
+		id, err := create()
+		if err != nil {
+			item.Status = Failed
+			item.Notes = append(item.Notes, err.Error())
+			// The object may have been created before a follow-up request
+			// failed, in which case create returns its ID along with the error
+			item.DestinationID = id
+		} else {
+			item.Status = Created
+			item.DestinationID = id
+		}
+	}
+
+	r.report.Items = append(r.report.Items, item)
+
+	if item.Status == WouldCreate {
+		return placeholderID(sourceID)
+	}
+	return item.DestinationID
+}
+
+func (r *restorer) skip(kind Kind, name, sourceID string, notes ...string) {
+	r.report.Items = append(r.report.Items, Item{Kind: kind, Name: name, SourceID: sourceID, Status: Skipped, Notes: notes})
+}
+
+func (r *restorer) restoreEnvironments() {
+	for _, e := range r.snap.Environments {
+		e := e
+		id := r.restore(EnvironmentKind, e.Name, e.ID, r.dest.envsByName[e.Name], nil, func() (string, error) {
+			resp, _, err := r.client.Environments.CreateEnvironment(r.ctx, &environments.CreateEnvironmentRequest{
+				Name:                       e.Name,
+				Description:                e.Description,
+				OfflineCleanupTimeoutHours: e.OfflineCleanupTimeoutHours,
+			})
+			if err != nil {
+				return "", err
+			}
+			return resp.ID, nil
+		})
+		if id != "" {
+			r.envIDs[e.ID] = id
+		}
+	}
+}
+
+func (r *restorer) restoreServiceAccounts() {
+	for _, sa := range r.snap.ServiceAccounts {
+		sa := sa
+		existingID := r.dest.serviceAccountsIDs[sa.Email]
+		if existingID == "" && r.onSecret == nil {
+			r.skip(ServiceAccountKind, sa.Email, sa.ID, "set RestoreOptions.OnServiceAccountCreated to receive the MFA secret of the new service account")
+			continue
+		}
+		notes := []string{"a new MFA secret was generated for the service account and passed to OnServiceAccountCreated"}
+		id := r.restore(ServiceAccountKind, sa.Email, sa.ID, existingID, notes, func() (string, error) {
+			resp, _, err := r.client.ServiceAccounts.CreateServiceAccount(r.ctx, &serviceaccounts.CreateServiceAccountRequest{
+				Email:          sa.Email,
+				JwksURL:        sa.JwksURL,
+				JwksURLPattern: sa.JwksURLPattern,
+				ExternalId:     sa.ExternalID,
+			})
+			if err != nil {
+				return "", err
+			}
+			newID := resp.ServiceAccountSummary.ID
+			r.onSecret(ServiceAccountSecret{Email: sa.Email, SourceID: sa.ID, DestinationID: newID, MFASecret: resp.MFASecret})
+			if sa.IsAdmin || !sa.Enabled {
+				_, _, err = r.client.ServiceAccounts.ModifyServiceAccount(r.ctx, newID, &serviceaccounts.ModifyServiceAccountRequest{
+					IsAdmin: bastionzero.PtrTo(sa.IsAdmin),
+					Enabled: bastionzero.PtrTo(sa.Enabled),
+				})
+				if err != nil {
+					return newID, fmt.Errorf("service account created but failed to set admin and enabled flags: %w", err)
+				}
+			}
+			return newID, nil
+		})
+		if id != "" {
+			r.dest.subjectsByEmail[sa.Email] = id
+		}
+	}
+}
+
+func (r *restorer) mapSubjectsAndGroups() {
+	for _, s := range r.snap.Subjects {
+		if id, ok := r.dest.subjectsByEmail[s.Email]; ok {
+			r.subjectIDs[s.ID] = id
+		}
+	}
+	for _, g := range r.snap.Groups {
+		if id, ok := r.dest.groupsByName[g.Name]; ok {
+			r.groupIDs[g.ID] = id
+		}
+	}
+}
+
+func (r *restorer) restoreTargets() {
+	// Bzero and Cluster targets are registered by their agents and cannot be
+	// created through the API. Map them by name so that policies and virtual
+	// targets referencing them can be restored.
+	for _, t := range r.snap.BzeroTargets {
+		r.mapAgentTarget(BzeroTargetKind, t.ID, t.Name, t.EnvironmentID)
+	}
+	for _, t := range r.snap.ClusterTargets {
+		r.mapAgentTarget(ClusterTargetKind, t.ID, t.Name, t.EnvironmentID)
+	}
+
+	for _, t := range r.snap.DatabaseTargets {
+		t := t
+		envID, notes, ok := r.virtualTargetPlacement(&t.VirtualTarget)
+		if !ok {
+			r.skip(DatabaseTargetKind, t.Name, t.ID, notes...)
+			continue
+		}
+		id := r.restore(DatabaseTargetKind, t.Name, t.ID, r.dest.targetsByKey[targetKey(DatabaseTargetKind, envID, t.Name)], notes, func() (string, error) {
+			req := &targets.CreateDatabaseTargetRequest{
+				TargetName:    t.Name,
+				RemoteHost:    t.RemoteHost,
+				RemotePort:    t.RemotePort,
+				LocalHost:     t.LocalHost,
+				EnvironmentID: envID,
+			}
+			if t.LocalPort.Value != nil {
+				req.LocalPort = &targets.Port{Value: t.LocalPort.Value}
+			}
+			r.setProxy(&t.VirtualTarget, &req.ProxyTargetID, &req.ProxyEnvironmentID)
+			authConfig := t.DatabaseAuthenticationConfig
+			req.DatabaseAuthenticationConfig = &authConfig
+			resp, _, err := r.client.Targets.CreateDatabaseTarget(r.ctx, req)
+			if err != nil {
+				return "", err
+			}
+			return resp.TargetId, nil
+		})
+		if id != "" {
+			r.targetIDs[t.ID] = id
+		}
+	}
+
+	for _, t := range r.snap.WebTargets {
+		t := t
+		envID, notes, ok := r.virtualTargetPlacement(&t.VirtualTarget)
+		if !ok {
+			r.skip(WebTargetKind, t.Name, t.ID, notes...)
+			continue
+		}
+		id := r.restore(WebTargetKind, t.Name, t.ID, r.dest.targetsByKey[targetKey(WebTargetKind, envID, t.Name)], notes, func() (string, error) {
+			req := &targets.CreateWebTargetRequest{
+				TargetName:    t.Name,
+				RemoteHost:    t.RemoteHost,
+				RemotePort:    t.RemotePort,
+				LocalHost:     t.LocalHost,
+				EnvironmentID: envID,
+			}
+			if t.LocalPort.Value != nil {
+				req.LocalPort = &targets.Port{Value: t.LocalPort.Value}
+			}
+			r.setProxy(&t.VirtualTarget, &req.ProxyTargetID, &req.ProxyEnvironmentID)
+			resp, _, err := r.client.Targets.CreateWebTarget(r.ctx, req)
+			if err != nil {
+				return "", err
+			}
+			return resp.TargetID, nil
+		})
+		if id != "" {
+			r.targetIDs[t.ID] = id
+		}
+	}
+
+	for _, d := range r.snap.DynamicAccessConfigurations {
+		d := d
+		envID, ok := r.envIDs[d.EnvironmentId]
+		if !ok {
+			r.skip(DynamicAccessConfigurationKind, d.Name, d.ID, fmt.Sprintf("environment %s was not restored", d.EnvironmentId))
+			continue
+		}
+		notes := []string{"the shared secret is not part of the snapshot and must be set with ModifyDynamicAccessConfiguration"}
+		id := r.restore(DynamicAccessConfigurationKind, d.Name, d.ID, r.dest.targetsByKey[targetKey(DynamicAccessConfigurationKind, envID, d.Name)], notes, func() (string, error) {
+			resp, _, err := r.client.Targets.CreateDynamicAccessConfiguration(r.ctx, &targets.CreateDynamicAccessConfigurationRequest{
+				Name:          d.Name,
+				StartWebhook:  d.StartWebhook,
+				StopWebhook:   d.StopWebhook,
+				HealthWebhook: d.HealthWebhook,
+				EnvironmentId: envID,
+			})
+			if err != nil {
+				return "", err
+			}
+			return resp.ID, nil
+		})
+		if id != "" {
+			r.targetIDs[d.ID] = id
+		}
+	}
+}
+
+func (r *restorer) mapAgentTarget(kind Kind, id, name, sourceEnvID string) {
+	envID, ok := r.envIDs[sourceEnvID]
+	if ok {
+		if existingID, ok := r.dest.targetsByKey[targetKey(kind, envID, name)]; ok {
+			r.targetIDs[id] = existingID
+			r.report.Items = append(r.report.Items, Item{Kind: kind, Name: name, SourceID: id, DestinationID: existingID, Status: Existing})
+			return
+		}
+	}
+	r.skip(kind, name, id, "agent-registered targets cannot be created through the API; install the agent in the destination organization and restore again")
+}
+
+// virtualTargetPlacement returns the destination environment ID of a virtual
+// target and checks that its proxy can be remapped
+func (r *restorer) virtualTargetPlacement(t *targets.VirtualTarget) (string, []string, bool) {
+	envID, ok := r.envIDs[t.EnvironmentID]
+	if !ok {
+		return "", []string{fmt.Sprintf("environment %s was not restored", t.EnvironmentID)}, false
+	}
+	if t.ProxyTargetID != "" {
+		if _, ok := r.targetIDs[t.ProxyTargetID]; !ok {
+			return "", []string{fmt.Sprintf("proxy target %s does not exist in the destination organization", t.ProxyTargetID)}, false
+		}
+	} else if t.ProxyEnvironmentID != "" {
+		if _, ok := r.envIDs[t.ProxyEnvironmentID]; !ok {
+			return "", []string{fmt.Sprintf("proxy environment %s was not restored", t.ProxyEnvironmentID)}, false
+		}
+	}
+	return envID, nil, true
+}
+
+func (r *restorer) setProxy(t *targets.VirtualTarget, proxyTargetID, proxyEnvironmentID *string) {
+	if t.ProxyTargetID != "" {
+		*proxyTargetID = r.targetIDs[t.ProxyTargetID]
+	} else {
+		*proxyEnvironmentID = r.envIDs[t.ProxyEnvironmentID]
+	}
+}
+
+// remapPolicy copies the common fields of a policy, remapping subject and
+// group IDs. Subjects and groups that do not exist in the destination
+// organization are dropped and reported in notes. Returns false if the policy
+// has already expired.
+func (r *restorer) remapPolicy(p *policies.Policy, notes *[]string) (policies.Policy, bool) {
+	if p.TimeExpires != nil && p.TimeExpires.Before(time.Now()) {
+		return policies.Policy{}, false
+	}
+
+	np := policies.Policy{Name: p.Name, Description: p.Description, TimeExpires: p.TimeExpires}
+	if p.Subjects != nil {
+		np.Subjects = bastionzero.PtrTo(r.remapSubjects(*p.Subjects, notes))
+	}
+	if p.Groups != nil {
+		np.Groups = bastionzero.PtrTo(r.remapGroups(*p.Groups, notes))
+	}
+	return np, true
+}
+
+func (r *restorer) remapSubjects(subjects []policies.Subject, notes *[]string) []policies.Subject {
+	result := []policies.Subject{}
+	for _, s := range subjects {
+		if id, ok := r.subjectIDs[s.ID]; ok {
+			result = append(result, policies.Subject{ID: id, Type: s.Type})
+		} else {
+			*notes = append(*notes, fmt.Sprintf("subject %s does not exist in the destination organization", r.subjectEmail(s.ID)))
+		}
+	}
+	return result
+}
+
+func (r *restorer) remapGroups(groups []policies.Group, notes *[]string) []policies.Group {
+	result := []policies.Group{}
+	for _, g := range groups {
+		if id, ok := r.groupIDs[g.ID]; ok {
+			result = append(result, policies.Group{ID: id, Name: g.Name})
+		} else {
+			*notes = append(*notes, fmt.Sprintf("group %q does not exist in the destination organization", g.Name))
+		}
+	}
+	return result
+}
+
+func (r *restorer) remapEnvironments(envs *[]policies.Environment, notes *[]string) *[]policies.Environment {
+	if envs == nil {
+		return nil
+	}
+	result := []policies.Environment{}
+	for _, e := range *envs {
+		if id, ok := r.envIDs[e.ID]; ok {
+			result = append(result, policies.Environment{ID: id})
+		} else {
+			*notes = append(*notes, fmt.Sprintf("environment %s was not restored", e.ID))
+		}
+	}
+	return &result
+}
+
+func (r *restorer) remapTargets(ts *[]policies.Target, notes *[]string) *[]policies.Target {
+	if ts == nil {
+		return nil
+	}
+	result := []policies.Target{}
+	for _, t := range *ts {
+		if id, ok := r.targetIDs[t.ID]; ok {
+			result = append(result, policies.Target{ID: id, Type: t.Type})
+		} else {
+			*notes = append(*notes, fmt.Sprintf("%s target %s was not restored", t.Type, t.ID))
+		}
+	}
+	return &result
+}
+
+func (r *restorer) remapClusters(cs *[]policies.Cluster, notes *[]string) *[]policies.Cluster {
+	if cs == nil {
+		return nil
+	}
+	result := []policies.Cluster{}
+	for _, c := range *cs {
+		if id, ok := r.targetIDs[c.ID]; ok {
+			result = append(result, policies.Cluster{ID: id})
+		} else {
+			*notes = append(*notes, fmt.Sprintf("%s target %s was not restored", targettype.Cluster, c.ID))
+		}
+	}
+	return &result
+}
+
+func (r *restorer) subjectEmail(id string) string {
+	for _, s := range r.snap.Subjects {
+		if s.ID == id {
+			return s.Email
+		}
+	}
+	return id
+}
+
+func (r *restorer) restorePolicies() {
+	const expiredNote = "policy has already expired"
+
+	for _, p := range r.snap.Policies.TargetConnect {
+		p := p
+		var notes []string
+		base, ok := r.remapPolicy(&p.Policy, &notes)
+		if !ok {
+			r.skip(TargetConnectPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		np := &policies.TargetConnectPolicy{
+			Policy:       base,
+			Environments: r.remapEnvironments(p.Environments, &notes),
+			Targets:      r.remapTargets(p.Targets, &notes),
+			TargetUsers:  p.TargetUsers,
+			Verbs:        p.Verbs,
+		}
+		r.policyIDs[p.ID] = r.restore(TargetConnectPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(TargetConnectPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateTargetConnectPolicy(r.ctx, np)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+
+	for _, p := range r.snap.Policies.Kubernetes {
+		p := p
+		var notes []string
+		base, ok := r.remapPolicy(&p.Policy, &notes)
+		if !ok {
+			r.skip(KubernetesPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		np := &policies.KubernetesPolicy{
+			Policy:        base,
+			Environments:  r.remapEnvironments(p.Environments, &notes),
+			Clusters:      r.remapClusters(p.Clusters, &notes),
+			ClusterUsers:  p.ClusterUsers,
+			ClusterGroups: p.ClusterGroups,
+		}
+		r.policyIDs[p.ID] = r.restore(KubernetesPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(KubernetesPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateKubernetesPolicy(r.ctx, np)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+
+	for _, p := range r.snap.Policies.Proxy {
+		p := p
+		var notes []string
+		base, ok := r.remapPolicy(&p.Policy, &notes)
+		if !ok {
+			r.skip(ProxyPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		np := &policies.ProxyPolicy{
+			Policy:       base,
+			Environments: r.remapEnvironments(p.Environments, &notes),
+			Targets:      r.remapTargets(p.Targets, &notes),
+			TargetUsers:  p.TargetUsers,
+		}
+		r.policyIDs[p.ID] = r.restore(ProxyPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(ProxyPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateProxyPolicy(r.ctx, np)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+
+	for _, p := range r.snap.Policies.OrganizationControls {
+		p := p
+		var notes []string
+		base, ok := r.remapPolicy(&p.Policy, &notes)
+		if !ok {
+			r.skip(OrganizationControlsPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		np := &policies.OrganizationControlsPolicy{Policy: base, MFAEnabled: p.MFAEnabled, MFADuration: p.MFADuration}
+		r.policyIDs[p.ID] = r.restore(OrganizationControlsPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(OrganizationControlsPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateOrganizationControlsPolicy(r.ctx, np)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+
+	for _, p := range r.snap.Policies.SessionRecording {
+		p := p
+		var notes []string
+		base, ok := r.remapPolicy(&p.Policy, &notes)
+		if !ok {
+			r.skip(SessionRecordingPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		np := &policies.SessionRecordingPolicy{Policy: base, RecordInput: p.RecordInput}
+		r.policyIDs[p.ID] = r.restore(SessionRecordingPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(SessionRecordingPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateSessionRecordingPolicy(r.ctx, np)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+
+	// JIT policies are restored last because they reference other policies
+	for _, p := range r.snap.Policies.JIT {
+		p := p
+		if p.TimeExpires != nil && p.TimeExpires.Before(time.Now()) {
+			r.skip(JITPolicyKind, p.Name, p.ID, expiredNote)
+			continue
+		}
+		var notes []string
+		req := &policies.CreateJITPolicyRequest{
+			Name:                  p.Name,
+			Description:           p.Description,
+			TimeExpires:           p.TimeExpires,
+			Subjects:              r.remapSubjects(p.Subjects, &notes),
+			Groups:                r.remapGroups(p.Groups, &notes),
+			ChildPolicies:         []string{},
+			AutomaticallyApproved: p.AutomaticallyApproved,
+			Duration:              p.Duration,
+		}
+		for _, c := range p.ChildPolicies {
+			if id := r.policyIDs[c.ID]; id != "" {
+				req.ChildPolicies = append(req.ChildPolicies, id)
+			} else {
+				notes = append(notes, fmt.Sprintf("child policy %q was not restored", c.Name))
+			}
+		}
+		r.policyIDs[p.ID] = r.restore(JITPolicyKind, p.Name, p.ID, r.dest.policiesByKey[policyKey(JITPolicyKind, p.Name)], notes, func() (string, error) {
+			created, _, err := r.client.Policies.CreateJITPolicy(r.ctx, req)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+}
+
+func (r *restorer) restoreApiKeys() {
+	// API key secrets are only returned when a key is created, so recreating
+	// a key would silently break every client using the old secret
+	for _, k := range r.snap.ApiKeys {
+		if existing, ok := r.dest.apiKeysByName[k.Name]; ok && existing.IsRegistrationKey == k.IsRegistrationKey {
+			r.report.Items = append(r.report.Items, Item{Kind: ApiKeyKind, Name: k.Name, SourceID: k.ID, DestinationID: existing.ID, Status: Existing})
+			continue
+		}
+		r.skip(ApiKeyKind, k.Name, k.ID, "API keys are secrets and must be recreated manually")
+	}
+}
+
+func (r *restorer) restoreGitHubActions() {
+	for _, a := range r.snap.AuthorizedGitHubActions {
+		a := a
+		r.restore(AuthorizedGitHubActionKind, a.GitHubActionId, a.ID, r.dest.gitHubActions[a.GitHubActionId], nil, func() (string, error) {
+			created, _, err := r.client.GitHubActions.CreateAuthorizedGitHubAction(r.ctx, &githubactions.CreateAuthorizedGitHubActionRequest{GitHubActionId: a.GitHubActionId})
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		})
+	}
+}
+
+func (r *restorer) restoreRegistrationKeySettings() {
+	settings := r.snap.RegistrationKeySettings
+	if settings == nil || !settings.GlobalRegistrationKeyEnforced || settings.DefaultGlobalRegistrationKey == nil {
+		return
+	}
+
+	// The default registration key can only be restored if a registration
+	// key with the same name already exists in the destination organization
+	var sourceKeyName string
+	for _, k := range r.snap.ApiKeys {
+		if k.ID == *settings.DefaultGlobalRegistrationKey {
+			sourceKeyName = k.Name
+		}
+	}
+	destKey, ok := r.dest.apiKeysByName[sourceKeyName]
+	if sourceKeyName == "" || !ok || !destKey.IsRegistrationKey {
+		r.skip(RegistrationKeySettingsKind, "global registration key", *settings.DefaultGlobalRegistrationKey, "the default global registration key does not exist in the destination organization; create it and enable enforcement manually")
+		return
+	}
+
+	r.restore(RegistrationKeySettingsKind, "global registration key", *settings.DefaultGlobalRegistrationKey, "", nil, func() (string, error) {
+		_, _, err := r.client.Organization.EnableGlobalRegistrationKey(r.ctx, &organization.EnableGlobalRegistrationKeyRequest{DefaultRegistrationKeyId: destKey.ID})
+		if err != nil {
+			return "", err
+		}
+		return destKey.ID, nil
+	})
+}
@@ -0,0 +1,94 @@
+// Package snapshot exports and restores the configuration of a BastionZero
+// organization.
+//
+// A Snapshot is a versioned JSON archive of an organization's environments,
+// targets, dynamic access configurations, policies, service accounts, API key
+// metadata, authorized GitHub actions and registration key settings. Secrets
+// are never part of a snapshot.
+//
+// Snapshots can be restored into the same or a different organization. IDs are
+// remapped between organizations by name (environments, targets, policies,
+// groups) or email (subjects). Objects that cannot be recreated through the
+// API, such as targets registered by an agent or API key secrets, are listed in
+// the RestoreReport. Service accounts get new MFA secrets, which are passed to
+// RestoreOptions.OnServiceAccountCreated rather than written to the report.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/apikeys"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/environments"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/githubactions"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/organization"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/serviceaccounts"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/subjects"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+)
+
+// FormatVersion is the version of the snapshot format written by this package.
+// Read rejects snapshots with a newer version.
+const FormatVersion = 1
+
+// Snapshot is a point-in-time copy of an organization's configuration
+type Snapshot struct {
+	// Version is the snapshot format version
+	Version int `json:"version"`
+	// CreatedAt is the time the snapshot was exported
+	CreatedAt time.Time `json:"createdAt"`
+	// Organization is the organization the snapshot was exported from
+	Organization organization.Organization `json:"organization"`
+
+	Environments []environments.Environment `json:"environments"`
+
+	BzeroTargets                []targets.BzeroTarget                `json:"bzeroTargets"`
+	ClusterTargets              []targets.ClusterTarget              `json:"clusterTargets"`
+	DatabaseTargets             []targets.DatabaseTarget             `json:"databaseTargets"`
+	WebTargets                  []targets.WebTarget                  `json:"webTargets"`
+	DynamicAccessConfigurations []targets.DynamicAccessConfiguration `json:"dynamicAccessConfigurations"`
+
+	Policies Policies `json:"policies"`
+
+	// Subjects and Groups are used to remap the subject and group IDs
+	// referenced by policies when restoring into another organization
+	Subjects []subjects.Subject   `json:"subjects"`
+	Groups   []organization.Group `json:"groups"`
+
+	ServiceAccounts         []serviceaccounts.ServiceAccount       `json:"serviceAccounts"`
+	ApiKeys                 []apikeys.ApiKey                       `json:"apiKeys"`
+	AuthorizedGitHubActions []githubactions.AuthorizedGitHubAction `json:"authorizedGitHubActions"`
+	RegistrationKeySettings *organization.RegistrationKeySettings  `json:"registrationKeySettings"`
+}
+
+// Policies holds every type of policy
+type Policies struct {
+	TargetConnect        []policies.TargetConnectPolicy        `json:"targetConnect"`
+	Kubernetes           []policies.KubernetesPolicy           `json:"kubernetes"`
+	Proxy                []policies.ProxyPolicy                `json:"proxy"`
+	JIT                  []policies.JITPolicy                  `json:"jit"`
+	OrganizationControls []policies.OrganizationControlsPolicy `json:"organizationControls"`
+	SessionRecording     []policies.SessionRecordingPolicy     `json:"sessionRecording"`
+}
+
+// Write writes the snapshot to w as indented JSON
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Read reads a snapshot previously written with Write
+func Read(r io.Reader) (*Snapshot, error) {
+	s := new(Snapshot)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Version < 1 || s.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (supported versions: 1-%d)", s.Version, FormatVersion)
+	}
+	return s, nil
+}
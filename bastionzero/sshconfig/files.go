@@ -0,0 +1,126 @@
+package sshconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers delimiting the section of an SSH config managed by
+// UpdateManagedSection
+const (
+	BeginMarker = "# BEGIN BASTIONZERO MANAGED SECTION"
+	EndMarker   = "# END BASTIONZERO MANAGED SECTION"
+)
+
+const generatedHeader = "# Generated by the BastionZero SDK. Changes will be overwritten.\n\n"
+
+// WriteFile replaces the file at path with the Host blocks. Include it from
+// ~/.ssh/config with EnsureInclude.
+func WriteFile(path string, hosts []Host) error {
+	var b bytes.Buffer
+	b.WriteString(generatedHeader)
+	if err := Write(&b, hosts); err != nil {
+		return err
+	}
+	return writeAtomic(path, b.Bytes())
+}
+
+// EnsureInclude adds "Include <includePath>" to the top of the SSH config at
+// configPath unless it is already there. The config is created if it does
+// not exist. Include directives must come before any Host block to apply to
+// all hosts, so the line is prepended.
+func EnsureInclude(configPath string, includePath string) error {
+	existing, err := readIfExists(configPath)
+	if err != nil {
+		return err
+	}
+
+	directive := "Include " + includePath
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == directive {
+			return nil
+		}
+	}
+	return writeAtomic(configPath, append([]byte(directive+"\n\n"), existing...))
+}
+
+// UpdateManagedSection replaces the managed section of config with the Host
+// blocks and returns the result. The section is appended if config has none.
+// Everything outside the markers is kept as is.
+func UpdateManagedSection(config []byte, hosts []Host) ([]byte, error) {
+	var section bytes.Buffer
+	section.WriteString(BeginMarker + "\n")
+	if err := Write(&section, hosts); err != nil {
+		return nil, err
+	}
+	section.WriteString(EndMarker + "\n")
+
+	text := string(config)
+	begin := strings.Index(text, BeginMarker)
+	end := strings.Index(text, EndMarker)
+	switch {
+	case begin < 0 && end < 0:
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if text != "" {
+			text += "\n"
+		}
+		return []byte(text + section.String()), nil
+	case begin < 0 || end < begin:
+		return nil, fmt.Errorf("SSH config has mismatched BastionZero markers")
+	}
+
+	after := text[end+len(EndMarker):]
+	after = strings.TrimPrefix(after, "\n")
+	return []byte(text[:begin] + section.String() + after), nil
+}
+
+// UpdateManagedSectionFile applies UpdateManagedSection to the SSH config at
+// path, creating it if it does not exist
+func UpdateManagedSectionFile(path string, hosts []Host) error {
+	existing, err := readIfExists(path)
+	if err != nil {
+		return err
+	}
+	updated, err := UpdateManagedSection(existing, hosts)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, updated)
+}
+
+func readIfExists(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// writeAtomic replaces path with data. SSH refuses configs writable by
+// others, so the file is only readable and writable by its owner.
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,307 @@
+// Package sshconfig generates OpenSSH client configuration for the targets
+// accessible through BastionZero.
+//
+// Generate renders one Host block per target and allowed target user. Host
+// aliases and the ProxyCommand are rendered from text/template templates.
+// Target and user names are chosen outside the operator's control (a target's
+// agent picks its name when it registers), so they are never used verbatim:
+// the names passed to the host template are sanitized so that aliases never
+// contain whitespace or characters with special meaning to ssh, the values
+// passed to the ProxyCommand template are quoted for the shell ssh runs it
+// with, and values containing control characters are rejected so that a name
+// cannot inject directives into the config.
+//
+// The blocks can be written to a file included from ~/.ssh/config (see
+// WriteFile and EnsureInclude) or to a section of ~/.ssh/config delimited by
+// marker comments (see UpdateManagedSection). Both replace everything written
+// previously, so entries of targets that no longer exist are pruned.
+package sshconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets_disambiguated"
+	"github.com/bastionzero/bastionzero-sdk-go/internal"
+)
+
+const (
+	// DefaultHostTemplate is the default template of Host aliases. It
+	// avoids "@", which ssh would take as separating the user from the host.
+	DefaultHostTemplate = "bzero-{{.User}}--{{.Environment}}--{{.Target}}"
+	// DefaultProxyCommandTemplate is the default template of ProxyCommand
+	DefaultProxyCommandTemplate = "zli ssh-proxy --target {{.TargetID}} --user {{.User}} %p"
+)
+
+// Target is a target that can be reached over SSH
+type Target struct {
+	ID              string
+	Name            string
+	EnvironmentName string
+	Users           []string
+}
+
+// TemplateData is passed to the host and ProxyCommand templates.
+//
+// For the host template, every field is sanitized: every character other
+// than ASCII letters, digits, "-", "_" and "." is replaced with "_".
+//
+// For the ProxyCommand template, every field is quoted for the shell if it
+// contains characters other than ASCII letters, digits and "@%+=:,./_-", and
+// "%" is escaped as "%%" so that ssh does not expand it as a token. Use the
+// fields as separate words and do not quote them again in the template.
+type TemplateData struct {
+	Target      string
+	TargetID    string
+	Environment string
+	User        string
+}
+
+// Options specifies the optional parameters to Generate
+type Options struct {
+	// HostTemplate renders each Host alias. Defaults to DefaultHostTemplate.
+	HostTemplate string
+	// ProxyCommandTemplate renders each ProxyCommand. Defaults to
+	// DefaultProxyCommandTemplate.
+	ProxyCommandTemplate string
+	// IdentityFile is set on every Host block if not empty
+	IdentityFile string
+	// ExtraOptions are added to every Host block, e.g. {"ServerAliveInterval": "30"}
+	ExtraOptions map[string]string
+}
+
+// Host is a rendered Host block
+type Host struct {
+	Alias        string
+	TargetID     string
+	User         string
+	ProxyCommand string
+	IdentityFile string
+	ExtraOptions map[string]string
+}
+
+// FromAllTargets returns the SSH and shell targets of a ListAllTargets
+// response. Targets listed as both are merged.
+func FromAllTargets(all *targets_disambiguated.AllTargetsResponse) []Target {
+	byID := make(map[string]*Target)
+	var order []string
+	add := func(t *targets_disambiguated.Target, users []policies.TargetUser) {
+		existing, ok := byID[t.ID]
+		if !ok {
+			existing = &Target{ID: t.ID, Name: t.Name, EnvironmentName: t.EnvironmentName}
+			byID[t.ID] = existing
+			order = append(order, t.ID)
+		}
+		existing.Users = appendUsers(existing.Users, users)
+	}
+	for i := range all.Ssh {
+		add(&all.Ssh[i].Target, all.Ssh[i].AllowedTargetUsers)
+	}
+	for i := range all.Shell {
+		add(&all.Shell[i].Target, all.Shell[i].AllowedTargetUsers)
+	}
+
+	result := make([]Target, len(order))
+	for i, id := range order {
+		result[i] = *byID[id]
+	}
+	return result
+}
+
+// FromBzeroTargets converts Bzero targets. environmentNames maps environment
+// IDs to names; the ID is used for environments missing from it.
+func FromBzeroTargets(list []targets.BzeroTarget, environmentNames map[string]string) []Target {
+	result := make([]Target, len(list))
+	for i, t := range list {
+		environment, ok := environmentNames[t.EnvironmentID]
+		if !ok {
+			environment = t.EnvironmentID
+		}
+		result[i] = Target{ID: t.ID, Name: t.Name, EnvironmentName: environment, Users: appendUsers(nil, t.AllowedTargetUsers)}
+	}
+	return result
+}
+
+// Fetch lists the SSH and shell targets accessible to the client and
+// generates Host blocks for them
+func Fetch(ctx context.Context, client *bastionzero.Client, opts *Options) ([]Host, error) {
+	all, _, err := client.AllTargets.ListAllTargets(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	return Generate(FromAllTargets(all), opts)
+}
+
+// Generate renders one Host block per target and user, sorted by alias. It
+// returns an error if a template is invalid, two blocks have the same alias,
+// or a rendered value contains control characters.
+func Generate(list []Target, opts *Options) ([]Host, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	hostTemplate, err := parseTemplate("host", opts.HostTemplate, DefaultHostTemplate)
+	if err != nil {
+		return nil, err
+	}
+	proxyTemplate, err := parseTemplate("proxy command", opts.ProxyCommandTemplate, DefaultProxyCommandTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []Host
+	seen := make(map[string]string)
+	for _, t := range list {
+		for _, user := range t.Users {
+			hostData := TemplateData{Target: internal.SanitizeName(t.Name), TargetID: internal.SanitizeName(t.ID), Environment: internal.SanitizeName(t.EnvironmentName), User: internal.SanitizeName(user)}
+			alias, err := render(hostTemplate, hostData)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := seen[alias]; ok {
+				return nil, fmt.Errorf("host alias %q is used by targets %s and %s", alias, other, t.ID)
+			}
+			seen[alias] = t.ID
+
+			proxyData := TemplateData{Target: proxyCommandQuote(t.Name), TargetID: proxyCommandQuote(t.ID), Environment: proxyCommandQuote(t.EnvironmentName), User: proxyCommandQuote(user)}
+			proxyCommand, err := render(proxyTemplate, proxyData)
+			if err != nil {
+				return nil, err
+			}
+			host := Host{
+				Alias:        alias,
+				TargetID:     t.ID,
+				User:         user,
+				ProxyCommand: proxyCommand,
+				IdentityFile: opts.IdentityFile,
+				ExtraOptions: opts.ExtraOptions,
+			}
+			if err := host.validate(); err != nil {
+				return nil, err
+			}
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Alias < hosts[j].Alias })
+	return hosts, nil
+}
+
+// Write writes the Host blocks to w. It returns an error without writing
+// anything if a value contains control characters or the alias contains
+// whitespace.
+func Write(w io.Writer, hosts []Host) error {
+	var b bytes.Buffer
+	for i, h := range hosts {
+		if err := h.validate(); err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# BastionZero target %s\n", h.TargetID)
+		fmt.Fprintf(&b, "Host %s\n", h.Alias)
+		fmt.Fprintf(&b, "    User %s\n", h.User)
+		fmt.Fprintf(&b, "    ProxyCommand %s\n", h.ProxyCommand)
+		if h.IdentityFile != "" {
+			fmt.Fprintf(&b, "    IdentityFile %s\n", h.IdentityFile)
+		}
+		keys := make([]string, 0, len(h.ExtraOptions))
+		for k := range h.ExtraOptions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "    %s %s\n", k, h.ExtraOptions[k])
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// validate returns an error if a value of h would break out of its line of
+// the config
+func (h *Host) validate() error {
+	if h.Alias == "" || strings.IndexFunc(h.Alias, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("host alias %q for target %s is empty or contains whitespace", h.Alias, h.TargetID)
+	}
+	fields := []struct{ name, value string }{
+		{"host alias", h.Alias},
+		{"target ID", h.TargetID},
+		{"User", h.User},
+		{"ProxyCommand", h.ProxyCommand},
+		{"IdentityFile", h.IdentityFile},
+	}
+	keys := make([]string, 0, len(h.ExtraOptions))
+	for k := range h.ExtraOptions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "" || strings.IndexFunc(k, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("option name %q for target %s is empty or contains whitespace", k, h.TargetID)
+		}
+		fields = append(fields, struct{ name, value string }{k, h.ExtraOptions[k]})
+	}
+	for _, f := range fields {
+		if strings.IndexFunc(f.value, unicode.IsControl) >= 0 {
+			return fmt.Errorf("%s %q for target %s contains control characters", f.name, f.value, h.TargetID)
+		}
+	}
+	return nil
+}
+
+// proxyCommandQuote quotes s as a single shell word and escapes "%", which
+// ssh expands as a token in ProxyCommand
+func proxyCommandQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+	}) < 0
+	if !safe {
+		s = "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+func parseTemplate(name string, text string, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func render(t *template.Template, data TemplateData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+func appendUsers(users []string, add []policies.TargetUser) []string {
+	for _, u := range add {
+		found := false
+		for _, e := range users {
+			if e == u.Username {
+				found = true
+				break
+			}
+		}
+		if !found {
+			users = append(users, u.Username)
+		}
+	}
+	return users
+}
@@ -0,0 +1,34 @@
+package bastionzero
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/decoding"
+)
+
+// DecodeHook is called by a client created with WithStrictDecoding when a
+// response contains unknown enum values or fields
+type DecodeHook func(req *http.Request, report *decoding.Report)
+
+// decodeStrict decodes the response body into v and reports anything unknown
+// to the client's decode hook
+func (c *Client) decodeStrict(req *http.Request, resp *http.Response, v interface{}) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		// ignore empty response body
+		return nil
+	}
+
+	report, err := decoding.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	if !report.Empty() {
+		c.decodeHook(req, report)
+	}
+	return nil
+}
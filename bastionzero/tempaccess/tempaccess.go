@@ -0,0 +1,327 @@
+// Package tempaccess manages temporary access grants.
+//
+// A grant is a time-boxed copy of a target connect, Kubernetes or proxy policy
+// that applies to a single subject. Grants rely on the policy's TimeExpires so
+// that the server deletes them even if nothing else is running. Grants are
+// recognized by their name prefix, which lets a Manager list, extend and
+// revoke grants created by other processes.
+//
+// Break-glass grants are grants for emergency access. When a break-glass grant
+// is revoked, or a Watcher observes that it expired, the subject's open
+// connections are closed so that access does not outlive the grant.
+package tempaccess
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/policies"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/types"
+)
+
+const (
+	// GrantNamePrefix is the name prefix of regular grants
+	GrantNamePrefix = "temp-"
+	// BreakGlassNamePrefix is the name prefix of break-glass grants
+	BreakGlassNamePrefix = "breakglass-"
+)
+
+// Grant is a temporary copy of a policy that applies to a single subject
+type Grant struct {
+	// Policy is the policy backing the grant. It is a *TargetConnectPolicy,
+	// *KubernetesPolicy or *ProxyPolicy.
+	Policy policies.PolicyInterface
+	// BreakGlass is true if the subject's connections are closed when the
+	// grant is revoked or expires
+	BreakGlass bool
+	// Expires is the time the server deletes the grant
+	Expires time.Time
+}
+
+// ID returns the ID of the policy backing the grant
+func (g *Grant) ID() string { return g.Policy.GetID() }
+
+// SubjectIDs returns the IDs of the subjects the grant applies to
+func (g *Grant) SubjectIDs() []string {
+	subjects := g.Policy.GetSubjects()
+	ids := make([]string, len(subjects))
+	for i, s := range subjects {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// GrantOptions specifies the optional parameters to Manager.Grant
+type GrantOptions struct {
+	// BreakGlass creates a break-glass grant
+	BreakGlass bool
+	// Reason is stored in the grant's description
+	Reason string
+}
+
+// revokedRetention is how long a Manager remembers revoked grants for a
+// Watcher that has not observed them yet
+const revokedRetention = 24 * time.Hour
+
+// Manager creates and manages grants
+type Manager struct {
+	// Client must be authenticated as an admin
+	Client *bastionzero.Client
+
+	mu sync.Mutex
+	// revoked holds the time each grant was revoked by ID, so that a Watcher
+	// does not handle a revoked grant as expired
+	revoked map[string]time.Time
+}
+
+// NewManager returns a Manager that uses client
+func NewManager(client *bastionzero.Client) *Manager {
+	return &Manager{Client: client}
+}
+
+// Grant creates a copy of source that applies only to subject and expires
+// after duration. source must be a *TargetConnectPolicy, *KubernetesPolicy or
+// *ProxyPolicy.
+func (m *Manager) Grant(ctx context.Context, source policies.PolicyInterface, subject policies.Subject, duration time.Duration, opts *GrantOptions) (*Grant, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	if opts == nil {
+		opts = &GrantOptions{}
+	}
+
+	prefix := GrantNamePrefix
+	if opts.BreakGlass {
+		prefix = BreakGlassNamePrefix
+	}
+	expires := time.Now().Add(duration).UTC().Truncate(time.Second)
+
+	description := fmt.Sprintf("Temporary grant of %q", source.GetName())
+	if opts.Reason != "" {
+		description += ": " + opts.Reason
+	}
+	common := policies.Policy{
+		Name:        fmt.Sprintf("%s%s-%s-%d", prefix, source.GetName(), subject.ID, expires.Unix()),
+		Description: &description,
+		Subjects:    &[]policies.Subject{subject},
+		Groups:      &[]policies.Group{},
+		TimeExpires: &types.Timestamp{Time: expires},
+	}
+
+	created, err := m.create(ctx, source, common)
+	if err != nil {
+		return nil, err
+	}
+	return &Grant{Policy: created, BreakGlass: opts.BreakGlass, Expires: expires}, nil
+}
+
+// ListGrants lists all grants in the organization
+func (m *Manager) ListGrants(ctx context.Context) ([]Grant, error) {
+	var all []policies.PolicyInterface
+
+	tcPolicies, _, err := m.Client.Policies.ListTargetConnectPolicies(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list target connect policies: %w", err)
+	}
+	for i := range tcPolicies {
+		all = append(all, &tcPolicies[i])
+	}
+	kubePolicies, _, err := m.Client.Policies.ListKubernetesPolicies(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Kubernetes policies: %w", err)
+	}
+	for i := range kubePolicies {
+		all = append(all, &kubePolicies[i])
+	}
+	proxyPolicies, _, err := m.Client.Policies.ListProxyPolicies(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy policies: %w", err)
+	}
+	for i := range proxyPolicies {
+		all = append(all, &proxyPolicies[i])
+	}
+
+	var grants []Grant
+	for _, p := range all {
+		if g, ok := grantFromPolicy(p); ok {
+			grants = append(grants, *g)
+		}
+	}
+	return grants, nil
+}
+
+// ListExpiring lists grants that have not expired yet but expire within the
+// given window
+func (m *Manager) ListExpiring(ctx context.Context, within time.Duration) ([]Grant, error) {
+	grants, err := m.ListGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deadline := now.Add(within)
+	var expiring []Grant
+	for _, g := range grants {
+		// The server may not have deleted an expired grant yet
+		if g.Expires.After(now) && !g.Expires.After(deadline) {
+			expiring = append(expiring, g)
+		}
+	}
+	return expiring, nil
+}
+
+// Extend pushes the expiration of grant back by the given duration. Since a
+// policy's TimeExpires cannot be modified, the grant is replaced by a new
+// policy and the old one is deleted. If deleting the old policy fails, the new
+// grant is returned along with the error.
+func (m *Manager) Extend(ctx context.Context, grant *Grant, by time.Duration) (*Grant, error) {
+	if by <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+
+	expires := grant.Expires.Add(by).UTC().Truncate(time.Second)
+	description := grant.Policy.GetDescription()
+	subjects := grant.Policy.GetSubjects()
+	common := policies.Policy{
+		Name:        fmt.Sprintf("%s-%d", grantBaseName(grant.Policy.GetName()), expires.Unix()),
+		Description: &description,
+		Subjects:    &subjects,
+		Groups:      &[]policies.Group{},
+		TimeExpires: &types.Timestamp{Time: expires},
+	}
+
+	created, err := m.create(ctx, grant.Policy, common)
+	if err != nil {
+		return nil, err
+	}
+	extended := &Grant{Policy: created, BreakGlass: grant.BreakGlass, Expires: expires}
+
+	if err := m.delete(ctx, grant.Policy); err != nil {
+		return extended, fmt.Errorf("extended grant %s but failed to delete the old grant %s: %w", created.GetID(), grant.ID(), err)
+	}
+	return extended, nil
+}
+
+// Revoke deletes grant before it expires. If grant is a break-glass grant, the
+// connections of its subjects are closed as well.
+func (m *Manager) Revoke(ctx context.Context, grant *Grant) error {
+	if err := m.delete(ctx, grant.Policy); err != nil {
+		return fmt.Errorf("failed to delete grant %s: %w", grant.ID(), err)
+	}
+	m.markRevoked(grant.ID())
+	if grant.BreakGlass {
+		return m.closeConnections(ctx, grant)
+	}
+	return nil
+}
+
+func (m *Manager) markRevoked(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.revoked == nil {
+		m.revoked = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for revokedID, at := range m.revoked {
+		if now.Sub(at) > revokedRetention {
+			delete(m.revoked, revokedID)
+		}
+	}
+	m.revoked[id] = now
+}
+
+// takeRevoked returns true if the grant was revoked by m and forgets it
+func (m *Manager) takeRevoked(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.revoked[id]
+	delete(m.revoked, id)
+	return ok
+}
+
+func (m *Manager) closeConnections(ctx context.Context, grant *Grant) error {
+	for _, id := range grant.SubjectIDs() {
+		if _, err := m.Client.Subjects.CloseSubjectConnections(ctx, id); err != nil {
+			return fmt.Errorf("failed to close connections of subject %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// create creates a policy of the same type as source with the fields of
+// source and the common fields replaced by common
+func (m *Manager) create(ctx context.Context, source policies.PolicyInterface, common policies.Policy) (policies.PolicyInterface, error) {
+	switch p := source.(type) {
+	case *policies.TargetConnectPolicy:
+		grant := *p
+		grant.Policy = common
+		created, _, err := m.Client.Policies.CreateTargetConnectPolicy(ctx, &grant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create target connect policy %q: %w", common.Name, err)
+		}
+		return created, nil
+	case *policies.KubernetesPolicy:
+		grant := *p
+		grant.Policy = common
+		created, _, err := m.Client.Policies.CreateKubernetesPolicy(ctx, &grant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes policy %q: %w", common.Name, err)
+		}
+		return created, nil
+	case *policies.ProxyPolicy:
+		grant := *p
+		grant.Policy = common
+		created, _, err := m.Client.Policies.CreateProxyPolicy(ctx, &grant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy policy %q: %w", common.Name, err)
+		}
+		return created, nil
+	default:
+		return nil, fmt.Errorf("unsupported policy type %s", source.GetPolicyType())
+	}
+}
+
+func (m *Manager) delete(ctx context.Context, policy policies.PolicyInterface) error {
+	var err error
+	switch policy.(type) {
+	case *policies.TargetConnectPolicy:
+		_, err = m.Client.Policies.DeleteTargetConnectPolicy(ctx, policy.GetID())
+	case *policies.KubernetesPolicy:
+		_, err = m.Client.Policies.DeleteKubernetesPolicy(ctx, policy.GetID())
+	case *policies.ProxyPolicy:
+		_, err = m.Client.Policies.DeleteProxyPolicy(ctx, policy.GetID())
+	default:
+		err = fmt.Errorf("unsupported policy type %s", policy.GetPolicyType())
+	}
+	return err
+}
+
+// grantFromPolicy returns the grant backed by p if p is a grant
+func grantFromPolicy(p policies.PolicyInterface) (*Grant, bool) {
+	name := p.GetName()
+	breakGlass := strings.HasPrefix(name, BreakGlassNamePrefix)
+	if !breakGlass && !strings.HasPrefix(name, GrantNamePrefix) {
+		return nil, false
+	}
+	if p.GetTimeExpires() == nil {
+		return nil, false
+	}
+	return &Grant{Policy: p, BreakGlass: breakGlass, Expires: p.GetTimeExpires().Time}, true
+}
+
+// grantBaseName strips the expiration suffix from a grant's name
+func grantBaseName(name string) string {
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return name
+	}
+	if _, err := strconv.ParseInt(name[i+1:], 10, 64); err != nil {
+		return name
+	}
+	return name[:i]
+}
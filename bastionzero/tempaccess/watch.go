@@ -0,0 +1,181 @@
+package tempaccess
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotificationType describes why a Watcher sends a notification
+type NotificationType string
+
+const (
+	// ExpiringSoon is sent once per grant when it is about to expire
+	ExpiringSoon NotificationType = "ExpiringSoon"
+	// Expired is sent once per grant when it has expired or no longer
+	// exists. It is not sent for grants revoked through the Watcher's Manager
+	// or replaced by Manager.Extend.
+	Expired NotificationType = "Expired"
+)
+
+// Notification is sent by a Watcher to its notifiers
+type Notification struct {
+	Type  NotificationType
+	Grant Grant
+}
+
+// Notifier receives notifications from a Watcher
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierFunc adapts an ordinary function to the Notifier interface
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+// Notify calls f(ctx, n)
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+const (
+	defaultWatchInterval = time.Minute
+	defaultNotifyBefore  = 15 * time.Minute
+)
+
+// Watcher periodically lists grants, notifies before they expire, and closes
+// the connections of a break-glass grant's subjects once it has expired
+type Watcher struct {
+	Manager *Manager
+	// Interval is the time between checks. Defaults to 1 minute.
+	Interval time.Duration
+	// NotifyBefore is how long before expiry ExpiringSoon is sent. Defaults
+	// to 15 minutes.
+	NotifyBefore time.Duration
+	Notifiers    []Notifier
+	// OnError is called with errors from listing grants, closing connections
+	// and notifiers. Errors are dropped if nil.
+	OnError func(error)
+
+	// seen holds the grants observed in the previous check by ID
+	seen map[string]Grant
+	// warned holds the IDs of grants ExpiringSoon was sent for
+	warned map[string]struct{}
+	// expired holds the IDs of expired grants that were handled while the
+	// server still listed them
+	expired map[string]struct{}
+}
+
+// Run checks grants immediately and then once per interval until ctx is
+// cancelled. Run returns ctx.Err() when ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) check(ctx context.Context) {
+	if w.seen == nil {
+		w.seen = make(map[string]Grant)
+		w.warned = make(map[string]struct{})
+		w.expired = make(map[string]struct{})
+	}
+	notifyBefore := w.NotifyBefore
+	if notifyBefore <= 0 {
+		notifyBefore = defaultNotifyBefore
+	}
+
+	grants, err := w.Manager.ListGrants(ctx)
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+
+	now := time.Now()
+	current := make(map[string]Grant, len(grants))
+	currentNames := make(map[string]struct{}, len(grants))
+	listedExpired := make(map[string]struct{})
+	for _, g := range grants {
+		// The server may not have deleted an expired grant yet, e.g. one that
+		// expired before the Watcher started. Handle it once.
+		if !g.Expires.After(now) {
+			listedExpired[g.ID()] = struct{}{}
+			if _, ok := w.expired[g.ID()]; !ok {
+				w.expired[g.ID()] = struct{}{}
+				w.expire(ctx, g)
+			}
+			continue
+		}
+		current[g.ID()] = g
+		currentNames[grantBaseName(g.Policy.GetName())] = struct{}{}
+
+		if _, ok := w.warned[g.ID()]; !ok && g.Expires.Sub(now) <= notifyBefore {
+			w.warned[g.ID()] = struct{}{}
+			w.notify(ctx, Notification{Type: ExpiringSoon, Grant: g})
+		}
+	}
+
+	for id, g := range w.seen {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		delete(w.warned, id)
+		if _, ok := w.expired[id]; ok {
+			// The grant was handled when it was listed as expired
+			continue
+		}
+		if _, ok := currentNames[grantBaseName(g.Policy.GetName())]; ok {
+			// The grant was replaced by Manager.Extend
+			continue
+		}
+		if w.Manager.takeRevoked(id) {
+			// Manager.Revoke already closed the connections of a break-glass
+			// grant
+			continue
+		}
+		w.expire(ctx, g)
+	}
+	for id := range w.expired {
+		if _, ok := listedExpired[id]; !ok {
+			delete(w.expired, id)
+		}
+	}
+	w.seen = current
+}
+
+// expire closes the connections of a break-glass grant and sends Expired
+func (w *Watcher) expire(ctx context.Context, g Grant) {
+	if g.BreakGlass {
+		if err := w.Manager.closeConnections(ctx, &g); err != nil {
+			w.reportError(err)
+		}
+	}
+	w.notify(ctx, Notification{Type: Expired, Grant: g})
+}
+
+func (w *Watcher) notify(ctx context.Context, n Notification) {
+	for _, notifier := range w.Notifiers {
+		if err := notifier.Notify(ctx, n); err != nil {
+			w.reportError(fmt.Errorf("failed to notify: %w", err))
+		}
+	}
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,60 @@
+package bastionzero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/service/targets/dbauthconfig"
+)
+
+// Validator is implemented by request and policy types that can be checked for
+// errors before they are sent to the BastionZero API. Validate returns an
+// *apierror.ValidationError if the value is invalid.
+type Validator interface {
+	Validate() error
+}
+
+// dbAuthConfigValidator is implemented by requests that set a
+// DatabaseAuthenticationConfig
+type dbAuthConfigValidator interface {
+	ValidateDatabaseAuthenticationConfig(supported []dbauthconfig.DatabaseAuthenticationConfig) error
+}
+
+func (c *Client) validateRequest(ctx context.Context, body interface{}) error {
+	v, ok := body.(Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
+	if dv, ok := body.(dbAuthConfigValidator); ok {
+		// Skip fetching the supported configurations if the request does not
+		// set one
+		if err := dv.ValidateDatabaseAuthenticationConfig(nil); err == nil {
+			return nil
+		}
+		supported, err := c.supportedDBAuthConfigs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch supported database authentication configurations: %w", err)
+		}
+		return dv.ValidateDatabaseAuthenticationConfig(supported)
+	}
+
+	return nil
+}
+
+func (c *Client) supportedDBAuthConfigs(ctx context.Context) ([]dbauthconfig.DatabaseAuthenticationConfig, error) {
+	c.dbAuthConfigsMu.Lock()
+	defer c.dbAuthConfigsMu.Unlock()
+
+	if c.dbAuthConfigs == nil {
+		configs, _, err := c.Targets.ListDatabaseAuthenticationConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.dbAuthConfigs = configs
+	}
+	return c.dbAuthConfigs, nil
+}
@@ -0,0 +1,84 @@
+// Command bzpolicylint lints the policies of a BastionZero organization.
+//
+// The API secret is read from the BASTIONZERO_API_SECRET environment variable.
+// The process exits with status 1 if any finding is at or above the -fail-on
+// severity, and with status 2 if the policies could not be linted.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/policylint"
+	"github.com/bastionzero/bastionzero-sdk-go/bastionzero/policylint/severity"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	baseURL := flag.String("base-url", bastionzero.DefaultBaseURL, "BastionZero API URL")
+	format := flag.String("format", "text", "output format: text or json")
+	failOn := flag.String("fail-on", string(severity.Warning), "lowest severity that causes a non-zero exit status: Info, Warning or Error")
+	disable := flag.String("disable", "", "comma-separated list of rules to disable")
+	flag.Parse()
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "invalid -format: %q\n", *format)
+		return 2
+	}
+	failOnSeverity := severity.Severity(*failOn)
+	if !failOnSeverity.Valid() {
+		fmt.Fprintf(os.Stderr, "invalid -fail-on severity: %q\n", *failOn)
+		return 2
+	}
+
+	apiSecret := os.Getenv("BASTIONZERO_API_SECRET")
+	if apiSecret == "" {
+		fmt.Fprintln(os.Stderr, "BASTIONZERO_API_SECRET must be set")
+		return 2
+	}
+
+	client, err := bastionzero.NewFromAPISecret(nil, apiSecret, bastionzero.WithBaseURL(*baseURL), bastionzero.WithUserAgent("bzpolicylint"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx := context.Background()
+	policies, err := policylint.FetchPolicies(ctx, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	inventory, err := policylint.FetchInventory(ctx, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	opts := policylint.DefaultOptions()
+	if *disable != "" {
+		for _, r := range strings.Split(*disable, ",") {
+			opts.DisabledRules = append(opts.DisabledRules, policylint.Rule(strings.TrimSpace(r)))
+		}
+	}
+
+	report := policylint.Lint(policies, inventory, opts)
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	return report.ExitCode(failOnSeverity)
+}
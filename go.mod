@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/google/go-querystring v1.1.0
 	github.com/lindell/string-enumer v1.0.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,17 @@
+package internal
+
+import "strings"
+
+// SanitizeName replaces every character of s other than ASCII letters, digits,
+// "-", "_" and "." with "_", making it safe to use in file names and in the
+// names of generated config entries
+func SanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}